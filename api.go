@@ -1,10 +1,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"html"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 func (app *App) fetchHomeTweets(count, sinceID, maxID string) (V2TweetsResponse, error) {
@@ -29,25 +33,47 @@ func (app *App) fetchHomeTweets(count, sinceID, maxID string) (V2TweetsResponse,
 	return res, err
 }
 
-func (app *App) fetchSearchTweets(query, count, since, until, sinceID string) (V2TweetsResponse, error) {
+func (app *App) fetchSearchTweets(query, count, since, until, sinceID string, archive bool) (V2TweetsResponse, error) {
 	params := v2TweetFields()
 	params["query"] = query
-	if count != "" {
-		params["max_results"] = count
+	setTimeRange(params, since, until)
+	if sinceID != "" {
+		params["since_id"] = sinceID
 	}
+
+	if !archive {
+		res, err := app.fetchTweetPages("https://api.twitter.com/2/tweets/search/recent", params, count, pageOptions{
+			TokenParam: "next_token",
+		})
+		return res, err
+	}
+
+	// full-archive search allows one request per second and up to 500
+	// tweets per page
+	res, err := app.fetchTweetPages("https://api.twitter.com/2/tweets/search/all", params, count, pageOptions{
+		TokenParam: "next_token",
+		MaxResults: 500,
+		Interval:   time.Second,
+	})
+	return res, archiveError(err, archive)
+}
+
+func setTimeRange(params map[string]string, since, until string) {
 	if since != "" && isTimeFormat(since) {
 		params["start_time"] = since + "T00:00:00Z"
 	}
 	if until != "" && isTimeFormat(until) {
 		params["end_time"] = until + "T23:59:59Z"
 	}
-	if sinceID != "" {
-		params["since_id"] = sinceID
-	}
+}
 
-	var res V2TweetsResponse
-	err := app.callGet("https://api.twitter.com/2/tweets/search/recent", params, &res)
-	return res, err
+// archiveError explains the usual cause when a full-archive endpoint is
+// refused, which is an API tier without full-archive access.
+func archiveError(err error, archive bool) error {
+	if archive && err != nil && strings.HasPrefix(err.Error(), "403") {
+		return fmt.Errorf("full-archive access is not available for this app's API tier: %v", err)
+	}
+	return err
 }
 
 func (app *App) fetchMentions(count string) (V2TweetsResponse, error) {
@@ -89,6 +115,67 @@ func (app *App) fetchUserTweets(username, count, sinceID, maxID string) (V2Tweet
 	return res, err
 }
 
+type pageOptions struct {
+	TokenParam string        // query parameter for the next page, "pagination_token" by default
+	MaxResults int           // largest page size the endpoint accepts, 100 by default
+	Interval   time.Duration // pause between requests for rate-limited endpoints
+}
+
+// fetchTweetPages follows next_token until count tweets were collected. An
+// empty count fetches a single page with the API default size.
+func (app *App) fetchTweetPages(uri string, params map[string]string, count string, opts pageOptions) (V2TweetsResponse, error) {
+	if opts.TokenParam == "" {
+		opts.TokenParam = "pagination_token"
+	}
+	if opts.MaxResults == 0 {
+		opts.MaxResults = 100
+	}
+	want, _ := strconv.Atoi(count)
+
+	var res V2TweetsResponse
+	for {
+		if want > 0 {
+			params["max_results"] = strconv.Itoa(min(max(want-len(res.Data), 10), opts.MaxResults))
+		}
+		var page V2TweetsResponse
+		if err := app.callGet(uri, params, &page); err != nil {
+			return V2TweetsResponse{}, err
+		}
+		res.Data = append(res.Data, page.Data...)
+		res.Includes.add(page.Includes)
+		if res.Meta.NewestID == "" {
+			res.Meta.NewestID = page.Meta.NewestID
+		}
+		res.Meta.OldestID = page.Meta.OldestID
+		res.Meta.NextToken = page.Meta.NextToken
+		if page.Meta.NextToken == "" || want <= 0 || len(res.Data) >= want {
+			break
+		}
+		params[opts.TokenParam] = page.Meta.NextToken
+		time.Sleep(opts.Interval)
+	}
+	if want > 0 && len(res.Data) > want {
+		res.Data = res.Data[:want]
+	}
+	res.Meta.ResultCount = len(res.Data)
+	return res, nil
+}
+
+func (app *App) fetchLikedTweets(user, count string) (V2TweetsResponse, error) {
+	var userID string
+	var err error
+	if user == "" {
+		userID, err = app.getMyID()
+	} else {
+		userID, err = app.resolveUserID(user)
+	}
+	if err != nil {
+		return V2TweetsResponse{}, err
+	}
+
+	return app.fetchTweetPages("https://api.twitter.com/2/users/"+userID+"/liked_tweets", v2TweetFields(), count, pageOptions{})
+}
+
 func (app *App) resolveListID(list string) (string, error) {
 	if _, err := strconv.ParseInt(list, 10, 64); err == nil {
 		return list, nil
@@ -96,38 +183,85 @@ func (app *App) resolveListID(list string) (string, error) {
 
 	part := strings.SplitN(list, "/", 2)
 
-	var ownerID string
+	var uris []string
 	if len(part) == 1 {
 		id, err := app.getMyID()
 		if err != nil {
 			return "", err
 		}
-		ownerID = id
+		uris = []string{
+			"https://api.twitter.com/2/users/" + id + "/owned_lists",
+			"https://api.twitter.com/2/users/" + id + "/followed_lists",
+			"https://api.twitter.com/2/users/" + id + "/list_memberships",
+		}
 	} else {
 		var userRes V2UserResponse
 		err := app.callGet("https://api.twitter.com/2/users/by/username/"+part[0], nil, &userRes)
 		if err != nil {
 			return "", err
 		}
-		ownerID = userRes.Data.ID
+		uris = []string{
+			"https://api.twitter.com/2/users/" + userRes.Data.ID + "/owned_lists",
+		}
 	}
 
 	slug := part[len(part)-1]
 
-	var listsRes V2ListsResponse
-	err := app.callGet("https://api.twitter.com/2/users/"+ownerID+"/owned_lists", map[string]string{
+	for _, uri := range uris {
+		id, err := app.findList(uri, slug)
+		if err != nil {
+			return "", err
+		}
+		if id != "" {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("list not found: %s", slug)
+}
+
+// findList pages through the lists at uri until it finds the one named
+// name, and returns its ID or an empty string when there is none.
+func (app *App) findList(uri string, name string) (string, error) {
+	params := map[string]string{
 		"list.fields": "name",
-	}, &listsRes)
-	if err != nil {
-		return "", err
+		"max_results": "100",
+	}
+	for {
+		var res V2ListsResponse
+		if err := app.callGet(uri, params, &res); err != nil {
+			return "", err
+		}
+		for _, l := range res.Data {
+			if strings.EqualFold(l.Name, name) {
+				return l.ID, nil
+			}
+		}
+		if res.Meta.NextToken == "" {
+			return "", nil
+		}
+		params["pagination_token"] = res.Meta.NextToken
+	}
+}
+
+func (app *App) fetchLists(uri string) ([]V2List, error) {
+	params := map[string]string{
+		"list.fields": "name,description,private,owner_id,member_count,follower_count",
+		"max_results": "100",
 	}
 
-	for _, l := range listsRes.Data {
-		if strings.EqualFold(l.Name, slug) {
-			return l.ID, nil
+	var lists []V2List
+	for {
+		var res V2ListsResponse
+		if err := app.callGet(uri, params, &res); err != nil {
+			return nil, err
+		}
+		lists = append(lists, res.Data...)
+		if res.Meta.NextToken == "" {
+			break
 		}
+		params["pagination_token"] = res.Meta.NextToken
 	}
-	return "", fmt.Errorf("list not found: %s", slug)
+	return lists, nil
 }
 
 func (app *App) fetchListTweets(list, count string) (V2TweetsResponse, error) {
@@ -146,18 +280,141 @@ func (app *App) fetchListTweets(list, count string) (V2TweetsResponse, error) {
 	return res, err
 }
 
-func (app *App) createTweet(text, inReplyTo string, mediaIDs []string) (string, error) {
+func (app *App) fetchTweet(tweetID string) (V2TweetLookupResponse, error) {
+	params := v2TweetFields()
+	params["tweet.fields"] += ",conversation_id,in_reply_to_user_id"
+
+	var res V2TweetLookupResponse
+	err := app.callGet("https://api.twitter.com/2/tweets/"+tweetID, params, &res)
+	return res, err
+}
+
+type tweetOptions struct {
+	InReplyTo     string
+	QuoteTweetID  string
+	MediaIDs      []string
+	PollOptions   []string
+	PollDuration  time.Duration
+	ReplySettings string
+	EditTweetID   string
+}
+
+const (
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+func parsePollOptions(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var options []string
+	for _, o := range strings.Split(s, "|") {
+		options = append(options, strings.TrimSpace(o))
+	}
+	return options
+}
+
+func validatePoll(opts tweetOptions) error {
+	if len(opts.PollOptions) < 2 || len(opts.PollOptions) > 4 {
+		return fmt.Errorf("poll needs 2 to 4 options, got %d", len(opts.PollOptions))
+	}
+	for _, o := range opts.PollOptions {
+		if o == "" {
+			return errors.New("poll option cannot be empty")
+		}
+		if utf8.RuneCountInString(o) > maxPollOptionLength {
+			return fmt.Errorf("poll option is longer than %d characters: %s", maxPollOptionLength, o)
+		}
+	}
+	if opts.PollDuration < minPollDuration || opts.PollDuration > maxPollDuration {
+		return fmt.Errorf("poll duration must be between %v and %v", minPollDuration, maxPollDuration)
+	}
+	if opts.PollDuration%time.Minute != 0 {
+		return fmt.Errorf("poll duration must be a whole number of minutes: %v", opts.PollDuration)
+	}
+	if len(opts.MediaIDs) > 0 {
+		return errors.New("cannot attach media to a poll")
+	}
+	if opts.QuoteTweetID != "" {
+		return errors.New("cannot quote a tweet in a poll")
+	}
+	return nil
+}
+
+func validateReplySettings(replySettings string) error {
+	switch replySettings {
+	case "", "following", "mentionedUsers", "subscribers":
+		return nil
+	}
+	return fmt.Errorf("invalid reply settings: %s (following, mentionedUsers or subscribers)", replySettings)
+}
+
+func validateTweetOptions(text string, opts tweetOptions) error {
+	if err := validateReplySettings(opts.ReplySettings); err != nil {
+		return err
+	}
+	if opts.EditTweetID != "" {
+		if opts.InReplyTo != "" || opts.QuoteTweetID != "" || len(opts.PollOptions) > 0 {
+			return errors.New("cannot change reply, quote or poll when editing a tweet")
+		}
+		if text == "" && len(opts.MediaIDs) == 0 {
+			return errors.New("text or media is required to edit a tweet")
+		}
+	}
+	if opts.QuoteTweetID != "" {
+		if len(opts.MediaIDs) > 0 {
+			return errors.New("cannot attach media to a quote tweet")
+		}
+		if text == "" {
+			return errors.New("text is required to quote a tweet")
+		}
+	}
+	if len(opts.PollOptions) > 0 {
+		if err := validatePoll(opts); err != nil {
+			return err
+		}
+		if text == "" {
+			return errors.New("text is required to post a poll")
+		}
+	}
+	return nil
+}
+
+func (app *App) createTweet(text string, opts tweetOptions) (string, error) {
+	if err := validateTweetOptions(text, opts); err != nil {
+		return "", err
+	}
+
 	body := map[string]any{
 		"text": text,
 	}
-	if inReplyTo != "" {
+	if opts.InReplyTo != "" {
 		body["reply"] = map[string]string{
-			"in_reply_to_tweet_id": inReplyTo,
+			"in_reply_to_tweet_id": opts.InReplyTo,
 		}
 	}
-	if len(mediaIDs) > 0 {
+	if opts.QuoteTweetID != "" {
+		body["quote_tweet_id"] = opts.QuoteTweetID
+	}
+	if len(opts.MediaIDs) > 0 {
 		body["media"] = map[string]any{
-			"media_ids": mediaIDs,
+			"media_ids": opts.MediaIDs,
+		}
+	}
+	if opts.ReplySettings != "" {
+		body["reply_settings"] = opts.ReplySettings
+	}
+	if opts.EditTweetID != "" {
+		body["edit_options"] = map[string]string{
+			"previous_post_id": opts.EditTweetID,
+		}
+	}
+	if len(opts.PollOptions) > 0 {
+		body["poll"] = map[string]any{
+			"options":          opts.PollOptions,
+			"duration_minutes": int(opts.PollDuration.Minutes()),
 		}
 	}
 	var res V2TweetResponse
@@ -168,6 +425,32 @@ func (app *App) createTweet(text, inReplyTo string, mediaIDs []string) (string,
 	return res.Data.ID, nil
 }
 
+// parseTweetID accepts a tweet ID or a status URL such as
+// https://x.com/user/status/123 and returns the ID.
+func parseTweetID(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		part := strings.Split(strings.Trim(u.Path, "/"), "/")
+		for i := 0; i+1 < len(part); i++ {
+			if part[i] == "status" || part[i] == "statuses" {
+				s = part[i+1]
+				break
+			}
+		}
+	}
+	if _, err := strconv.ParseUint(s, 10, 64); err != nil {
+		return "", fmt.Errorf("invalid tweet ID: %s", s)
+	}
+	return s, nil
+}
+
+func (app *App) hideReply(tweetID string, hidden bool) error {
+	body := map[string]bool{
+		"hidden": hidden,
+	}
+	return app.callPut("https://api.twitter.com/2/tweets/"+tweetID+"/hidden", body, nil)
+}
+
 func (app *App) likeTweet(tweetID string) error {
 	myID, err := app.getMyID()
 	if err != nil {
@@ -190,6 +473,257 @@ func (app *App) retweet(tweetID string) error {
 	return app.callPost("https://api.twitter.com/2/users/"+myID+"/retweets", body, nil)
 }
 
+// maxUsernameLength is the longest username X allows. Longer all-digit
+// input can only be a user ID.
+const maxUsernameLength = 15
+
+// parseUserRef splits a user given on the command line into a user ID or a
+// username. IDs are prefixed with "id:", or recognized as all-digit input
+// too long to be a username; anything else is a username, with or without
+// "@".
+func parseUserRef(user string) (id string, username string) {
+	if strings.HasPrefix(user, "id:") {
+		return user[len("id:"):], ""
+	}
+	if strings.HasPrefix(user, "@") {
+		return "", user[1:]
+	}
+	if len(user) > maxUsernameLength {
+		if _, err := strconv.ParseUint(user, 10, 64); err == nil {
+			return user, ""
+		}
+	}
+	return "", user
+}
+
+func (app *App) resolveUserID(user string) (string, error) {
+	id, user := parseUserRef(user)
+	if id != "" {
+		return id, nil
+	}
+
+	var userRes V2UserResponse
+	err := app.callGet("https://api.twitter.com/2/users/by/username/"+user, nil, &userRes)
+	if err != nil {
+		return "", err
+	}
+	if userRes.Data.ID == "" {
+		return "", fmt.Errorf("user not found: %s", user)
+	}
+	return userRes.Data.ID, nil
+}
+
+func (app *App) fetchUserProfiles(users []string) (V2UsersResponse, error) {
+	var ids, usernames []string
+	for _, user := range users {
+		if id, username := parseUserRef(user); id != "" {
+			ids = append(ids, id)
+		} else {
+			usernames = append(usernames, username)
+		}
+	}
+
+	var res V2UsersResponse
+	lookup := func(uri, key string, values []string) error {
+		for len(values) > 0 {
+			n := min(len(values), 100)
+			params := v2UserFields()
+			params[key] = strings.Join(values[:n], ",")
+			var page V2UsersResponse
+			if err := app.callGet(uri, params, &page); err != nil {
+				return err
+			}
+			res.Data = append(res.Data, page.Data...)
+			res.Includes.Tweets = append(res.Includes.Tweets, page.Includes.Tweets...)
+			res.Errors = append(res.Errors, page.Errors...)
+			values = values[n:]
+		}
+		return nil
+	}
+	if err := lookup("https://api.twitter.com/2/users", "ids", ids); err != nil {
+		return V2UsersResponse{}, err
+	}
+	if err := lookup("https://api.twitter.com/2/users/by", "usernames", usernames); err != nil {
+		return V2UsersResponse{}, err
+	}
+	return res, nil
+}
+
+// fetchUsers pages through a user list endpoint. A positive limit stops
+// after that many users, otherwise every page is fetched.
+func (app *App) fetchUsers(uri string, maxResults, limit int) ([]V2User, error) {
+	params := map[string]string{
+		"user.fields": "name,username,profile_image_url",
+		"max_results": strconv.Itoa(maxResults),
+	}
+
+	var users []V2User
+	for {
+		var res V2UsersResponse
+		if err := app.callGet(uri, params, &res); err != nil {
+			return nil, err
+		}
+		users = append(users, res.Data...)
+		if res.Meta.NextToken == "" || (limit > 0 && len(users) >= limit) {
+			break
+		}
+		params["pagination_token"] = res.Meta.NextToken
+	}
+	if limit > 0 && len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+func (app *App) fetchLikingUsers(tweetID, count string) ([]V2User, error) {
+	limit, _ := strconv.Atoi(count)
+	return app.fetchUsers("https://api.twitter.com/2/tweets/"+tweetID+"/liking_users", 100, limit)
+}
+
+func (app *App) fetchRetweetedBy(tweetID, count string) ([]V2User, error) {
+	limit, _ := strconv.Atoi(count)
+	return app.fetchUsers("https://api.twitter.com/2/tweets/"+tweetID+"/retweeted_by", 100, limit)
+}
+
+func (app *App) fetchQuoteTweets(tweetID, count string) (V2TweetsResponse, error) {
+	return app.fetchTweetPages("https://api.twitter.com/2/tweets/"+tweetID+"/quote_tweets", v2TweetFields(), count, pageOptions{})
+}
+
+func (app *App) fetchMuting() ([]V2User, error) {
+	myID, err := app.getMyID()
+	if err != nil {
+		return nil, err
+	}
+	return app.fetchUsers("https://api.twitter.com/2/users/"+myID+"/muting", 1000, 0)
+}
+
+func (app *App) fetchBlocking() ([]V2User, error) {
+	myID, err := app.getMyID()
+	if err != nil {
+		return nil, err
+	}
+	return app.fetchUsers("https://api.twitter.com/2/users/"+myID+"/blocking", 1000, 0)
+}
+
+func (app *App) muteUser(user string) error {
+	myID, err := app.getMyID()
+	if err != nil {
+		return err
+	}
+	targetID, err := app.resolveUserID(user)
+	if err != nil {
+		return err
+	}
+	body := map[string]string{
+		"target_user_id": targetID,
+	}
+	return app.callPost("https://api.twitter.com/2/users/"+myID+"/muting", body, nil)
+}
+
+func (app *App) unmuteUser(user string) error {
+	myID, err := app.getMyID()
+	if err != nil {
+		return err
+	}
+	targetID, err := app.resolveUserID(user)
+	if err != nil {
+		return err
+	}
+	return app.callDelete("https://api.twitter.com/2/users/"+myID+"/muting/"+targetID, nil)
+}
+
+func (app *App) blockUser(user string) error {
+	myID, err := app.getMyID()
+	if err != nil {
+		return err
+	}
+	targetID, err := app.resolveUserID(user)
+	if err != nil {
+		return err
+	}
+	body := map[string]string{
+		"target_user_id": targetID,
+	}
+	return app.callPost("https://api.twitter.com/2/users/"+myID+"/blocking", body, nil)
+}
+
+func (app *App) unblockUser(user string) error {
+	myID, err := app.getMyID()
+	if err != nil {
+		return err
+	}
+	targetID, err := app.resolveUserID(user)
+	if err != nil {
+		return err
+	}
+	return app.callDelete("https://api.twitter.com/2/users/"+myID+"/blocking/"+targetID, nil)
+}
+
+func formatUserCard(user V2User, tweetMap map[string]V2Tweet) string {
+	var lines []string
+	var flags []string
+	if user.Protected {
+		flags = append(flags, "protected")
+	}
+	if user.Verified {
+		if user.VerifiedType != "" && user.VerifiedType != "none" {
+			flags = append(flags, "verified ("+user.VerifiedType+")")
+		} else {
+			flags = append(flags, "verified")
+		}
+	}
+	if len(flags) > 0 {
+		lines = append(lines, strings.Join(flags, ", "))
+	}
+	if user.Description != "" {
+		lines = append(lines, html.UnescapeString(user.Description))
+	}
+	if user.Location != "" {
+		lines = append(lines, "Location: "+user.Location)
+	}
+	if user.URL != "" {
+		lines = append(lines, "URL: "+user.URL)
+	}
+	if user.CreatedAt != "" {
+		lines = append(lines, "Joined: "+user.CreatedAt)
+	}
+	if m := user.PublicMetrics; m != nil {
+		lines = append(lines, fmt.Sprintf("Following: %d, Followers: %d, Tweets: %d, Listed: %d",
+			m.FollowingCount, m.FollowersCount, m.TweetCount, m.ListedCount))
+	}
+	if user.PinnedTweetID != "" {
+		if pinned, ok := tweetMap[user.PinnedTweetID]; ok {
+			lines = append(lines, "Pinned ["+pinned.ID+"]: "+html.UnescapeString(fullText(pinned)))
+		} else {
+			lines = append(lines, "Pinned: "+user.PinnedTweetID)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatUsersText(res V2UsersResponse) string {
+	tweetMap := make(map[string]V2Tweet)
+	for _, t := range res.Includes.Tweets {
+		tweetMap[t.ID] = t
+	}
+
+	var sb strings.Builder
+	for _, user := range res.Data {
+		fmt.Fprintf(&sb, "@%s (%s) [%s]:\n", user.Username, user.Name, user.ID)
+		if card := formatUserCard(user, tweetMap); card != "" {
+			sb.WriteString(card + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	for _, e := range res.Errors {
+		fmt.Fprintf(&sb, "%s: %s\n", e.Value, e.Detail)
+	}
+	if sb.Len() == 0 {
+		return "No users found."
+	}
+	return strings.TrimSpace(sb.String())
+}
+
 func formatTweetsText(res V2TweetsResponse) string {
 	if len(res.Data) == 0 {
 		return "No tweets found."
@@ -204,12 +738,13 @@ func formatTweetsText(res V2TweetsResponse) string {
 		tweetMap[t.ID] = t
 	}
 
+	now := time.Now()
 	var sb strings.Builder
 	for i := len(res.Data) - 1; i >= 0; i-- {
 		tweet := res.Data[i]
 		user := userMap[tweet.AuthorID]
-		text := tweetText(tweet, tweetMap)
-		fmt.Fprintf(&sb, "@%s (%s) [%s]:\n%s\n\n", user.Username, user.Name, tweet.ID, html.UnescapeString(text))
+		text := tweetText(tweet, tweetMap, userMap)
+		fmt.Fprintf(&sb, "@%s (%s) [%s]%s:\n%s\n\n", user.Username, user.Name, tweet.ID, mcpTweetTime(tweet, now), html.UnescapeString(text))
 	}
 	return strings.TrimSpace(sb.String())
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func replyTo(id string) []V2ReferencedTweet {
+	return []V2ReferencedTweet{{Type: "replied_to", ID: id}}
+}
+
+func TestBuildThreadNesting(t *testing.T) {
+	res := V2TweetsResponse{
+		Data: []V2Tweet{
+			{ID: "3", Text: "reply to reply", AuthorID: "u1", ReferencedTweets: replyTo("2")},
+			{ID: "1", Text: "root", AuthorID: "u1"},
+			{ID: "2", Text: "reply", AuthorID: "u2", ReferencedTweets: replyTo("1")},
+			{ID: "3", Text: "duplicate", AuthorID: "u1", ReferencedTweets: replyTo("2")},
+		},
+		Includes: V2Includes{
+			Users: []V2User{{ID: "u1", Username: "alice"}, {ID: "u2", Username: "bob"}},
+		},
+	}
+	root := buildThread(res, "1")
+	if root.Tweet.ID != "1" || root.Author == nil || root.Author.Username != "alice" {
+		t.Fatalf("unexpected root: %+v", root)
+	}
+	if len(root.Replies) != 1 || root.Replies[0].Tweet.ID != "2" {
+		t.Fatalf("unexpected replies: %+v", root.Replies)
+	}
+	if r := root.Replies[0].Replies; len(r) != 1 || r[0].Tweet.ID != "3" {
+		t.Fatalf("unexpected nested replies: %+v", r)
+	}
+}
+
+func TestBuildThreadOrphansAttachToRoot(t *testing.T) {
+	res := V2TweetsResponse{
+		Data: []V2Tweet{
+			{ID: "1", Text: "root"},
+			{ID: "5", Text: "orphan", ReferencedTweets: replyTo("4")},
+		},
+	}
+	root := buildThread(res, "1")
+	if len(root.Replies) != 1 || root.Replies[0].Tweet.ID != "5" {
+		t.Fatalf("orphan not attached to root: %+v", root.Replies)
+	}
+}
+
+func TestBuildThreadSortsChronologically(t *testing.T) {
+	res := V2TweetsResponse{
+		Data: []V2Tweet{
+			{ID: "1"},
+			{ID: "100", ReferencedTweets: replyTo("1")},
+			{ID: "20", ReferencedTweets: replyTo("1")},
+		},
+	}
+	root := buildThread(res, "1")
+	if root.Replies[0].Tweet.ID != "20" || root.Replies[1].Tweet.ID != "100" {
+		t.Errorf("replies not in chronological order: %s, %s", root.Replies[0].Tweet.ID, root.Replies[1].Tweet.ID)
+	}
+}
+
+func TestFormatThreadTextIndents(t *testing.T) {
+	res := V2TweetsResponse{
+		Data: []V2Tweet{
+			{ID: "1", Text: "root", AuthorID: "u1"},
+			{ID: "2", Text: "reply", AuthorID: "u1", ReferencedTweets: replyTo("1")},
+		},
+		Includes: V2Includes{Users: []V2User{{ID: "u1", Name: "Alice", Username: "alice"}}},
+	}
+	got := formatThreadText(buildThread(res, "1"), nil, nil)
+	want := "@alice (Alice) [1]:\nroot\n\n  @alice (Alice) [2]:\n  reply"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if strings.Count(got, "@alice") != 2 {
+		t.Errorf("expected two tweets in %q", got)
+	}
+}
+
+func TestThreadDescendants(t *testing.T) {
+	tweets := []V2Tweet{
+		{ID: "2", ReferencedTweets: replyTo("1")},
+		{ID: "3", ReferencedTweets: replyTo("2")},
+		{ID: "4", ReferencedTweets: replyTo("1")},
+		{ID: "5", ReferencedTweets: replyTo("3")},
+	}
+	var got []string
+	for _, tweet := range threadDescendants(tweets, "2") {
+		got = append(got, tweet.ID)
+	}
+	if strings.Join(got, ",") != "2,3,5" {
+		t.Errorf("got %v, want [2 3 5]", got)
+	}
+}
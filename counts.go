@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+type V2TweetCount struct {
+	Start      string `json:"start"`
+	End        string `json:"end"`
+	TweetCount int    `json:"tweet_count"`
+}
+
+type V2TweetCountsResponse struct {
+	Data []V2TweetCount `json:"data"`
+	Meta struct {
+		TotalTweetCount int    `json:"total_tweet_count"`
+		NextToken       string `json:"next_token"`
+	} `json:"meta"`
+}
+
+const histogramWidth = 50
+
+var sparkRunes = []rune("▁▂▃▄▅▆▇█")
+
+func (app *App) fetchTweetCounts(query, granularity, since, until string, archive bool) (V2TweetCountsResponse, error) {
+	switch granularity {
+	case "minute", "hour", "day":
+	default:
+		return V2TweetCountsResponse{}, fmt.Errorf("invalid granularity: %s (minute, hour or day)", granularity)
+	}
+
+	params := map[string]string{
+		"query":       query,
+		"granularity": granularity,
+	}
+	setTimeRange(params, since, until)
+
+	uri := "https://api.twitter.com/2/tweets/counts/recent"
+	if archive {
+		uri = "https://api.twitter.com/2/tweets/counts/all"
+	}
+
+	var res V2TweetCountsResponse
+	for {
+		var page V2TweetCountsResponse
+		if err := app.callGet(uri, params, &page); err != nil {
+			return V2TweetCountsResponse{}, archiveError(err, archive)
+		}
+		res.Data = append(res.Data, page.Data...)
+		res.Meta.TotalTweetCount += page.Meta.TotalTweetCount
+		if page.Meta.NextToken == "" {
+			break
+		}
+		params["next_token"] = page.Meta.NextToken
+		if archive {
+			time.Sleep(time.Second)
+		}
+	}
+	return res, nil
+}
+
+func sparkline(counts []V2TweetCount) string {
+	peak := 0
+	for _, c := range counts {
+		peak = max(peak, c.TweetCount)
+	}
+	var sb strings.Builder
+	for _, c := range counts {
+		i := 0
+		if peak > 0 {
+			i = c.TweetCount * (len(sparkRunes) - 1) / peak
+		}
+		sb.WriteRune(sparkRunes[i])
+	}
+	return sb.String()
+}
+
+// bucketLabel shortens the ISO start time of a bucket to the precision of
+// the granularity, e.g. "2017-05-01T13" for hourly counts.
+func bucketLabel(start, granularity string) string {
+	n := len(start)
+	switch granularity {
+	case "day":
+		n = len("2006-01-02")
+	case "hour":
+		n = len("2006-01-02T15")
+	case "minute":
+		n = len("2006-01-02T15:04")
+	}
+	if n > len(start) {
+		n = len(start)
+	}
+	return start[:n]
+}
+
+func showTweetCounts(res V2TweetCountsResponse, granularity, format string, asjson bool) {
+	if asjson || format == "json" {
+		for _, c := range res.Data {
+			json.NewEncoder(os.Stdout).Encode(c)
+		}
+		os.Stdout.Sync()
+		return
+	}
+	if format == "csv" {
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"start", "end", "tweet_count"})
+		for _, c := range res.Data {
+			w.Write([]string{c.Start, c.End, strconv.Itoa(c.TweetCount)})
+		}
+		w.Flush()
+		return
+	}
+
+	peak := 0
+	for _, c := range res.Data {
+		peak = max(peak, c.TweetCount)
+	}
+	for _, c := range res.Data {
+		width := 0
+		if peak > 0 {
+			width = c.TweetCount * histogramWidth / peak
+		}
+		fmt.Print(bucketLabel(c.Start, granularity) + " ")
+		color.Set(color.FgHiRed)
+		fmt.Print(strings.Repeat("█", width))
+		color.Set(color.Reset)
+		fmt.Printf(" %d\n", c.TweetCount)
+	}
+	fmt.Printf("total: %d %s\n", res.Meta.TotalTweetCount, sparkline(res.Data))
+}
+
+func (app *App) showTweetCounts() {
+	if app.search == "" {
+		log.Fatal("specify the search word with -s")
+	}
+	res, err := app.fetchTweetCounts(app.search, app.granularity, app.since, app.until, app.archive)
+	if err != nil {
+		log.Fatalf("cannot get tweet counts: %v", err)
+	}
+	showTweetCounts(res, app.granularity, app.output, app.asjson)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSparkline(t *testing.T) {
+	counts := []V2TweetCount{{TweetCount: 0}, {TweetCount: 7}, {TweetCount: 14}}
+	if got := sparkline(counts); got != "▁▄█" {
+		t.Errorf("got %q, want %q", got, "▁▄█")
+	}
+}
+
+func TestSparklineAllZero(t *testing.T) {
+	counts := []V2TweetCount{{}, {}}
+	if got := sparkline(counts); got != "▁▁" {
+		t.Errorf("got %q, want %q", got, "▁▁")
+	}
+}
+
+func TestBucketLabel(t *testing.T) {
+	start := "2017-05-01T13:45:00.000Z"
+	tests := map[string]string{
+		"day":    "2017-05-01",
+		"hour":   "2017-05-01T13",
+		"minute": "2017-05-01T13:45",
+	}
+	for granularity, want := range tests {
+		if got := bucketLabel(start, granularity); got != want {
+			t.Errorf("bucketLabel(%q) = %q, want %q", granularity, got, want)
+		}
+	}
+	if got := bucketLabel("2017", "day"); got != "2017" {
+		t.Errorf("short start = %q, want %q", got, "2017")
+	}
+}
+
+func TestFetchTweetCountsInvalidGranularity(t *testing.T) {
+	if _, err := testApp().fetchTweetCounts("go", "week", "", "", false); err == nil {
+		t.Errorf("expected error for invalid granularity")
+	}
+}
+
+func TestArchiveError(t *testing.T) {
+	err := errors.New("403 Forbidden: {}")
+	if got := archiveError(err, true); !strings.Contains(got.Error(), "full-archive") {
+		t.Errorf("expected tier explanation, got %v", got)
+	}
+	if got := archiveError(err, false); got != err {
+		t.Errorf("recent endpoint errors should pass through, got %v", got)
+	}
+	unauthorized := errors.New("401 Unauthorized: {}")
+	if got := archiveError(unauthorized, true); got != unauthorized {
+		t.Errorf("token errors should pass through, got %v", got)
+	}
+	if got := archiveError(nil, true); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
+
+func TestSetTimeRange(t *testing.T) {
+	params := map[string]string{}
+	setTimeRange(params, "2017-05-01", "bogus")
+	if params["start_time"] != "2017-05-01T00:00:00Z" {
+		t.Errorf("start_time = %q", params["start_time"])
+	}
+	if _, ok := params["end_time"]; ok {
+		t.Errorf("end_time should not be set for invalid date")
+	}
+}
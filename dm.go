@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+type V2DMEvent struct {
+	ID               string         `json:"id"`
+	EventType        string         `json:"event_type"`
+	Text             string         `json:"text,omitempty"`
+	SenderID         string         `json:"sender_id,omitempty"`
+	DMConversationID string         `json:"dm_conversation_id,omitempty"`
+	CreatedAt        string         `json:"created_at,omitempty"`
+	Attachments      *V2Attachments `json:"attachments,omitempty"`
+}
+
+type V2DMEventsResponse struct {
+	Data     []V2DMEvent `json:"data"`
+	Includes V2Includes  `json:"includes"`
+	Meta     V2Meta      `json:"meta"`
+}
+
+type V2DMSendResponse struct {
+	Data struct {
+		DMConversationID string `json:"dm_conversation_id"`
+		DMEventID        string `json:"dm_event_id"`
+	} `json:"data"`
+}
+
+func v2DMEventFields() map[string]string {
+	return map[string]string{
+		"dm_event.fields": "id,text,event_type,created_at,sender_id,dm_conversation_id,attachments",
+		"user.fields":     "name,username,profile_image_url",
+		"expansions":      "sender_id",
+		"event_types":     "MessageCreate",
+	}
+}
+
+func (app *App) fetchDMEvents(count string) (V2DMEventsResponse, error) {
+	params := v2DMEventFields()
+	if count != "" {
+		params["max_results"] = count
+	}
+
+	var res V2DMEventsResponse
+	err := app.callGet("https://api.twitter.com/2/dm_events", params, &res)
+	return res, err
+}
+
+func (app *App) fetchDMConversation(user, count string) (V2DMEventsResponse, error) {
+	userID, err := app.resolveUserID(user)
+	if err != nil {
+		return V2DMEventsResponse{}, err
+	}
+
+	params := v2DMEventFields()
+	if count != "" {
+		params["max_results"] = count
+	}
+
+	var res V2DMEventsResponse
+	err = app.callGet("https://api.twitter.com/2/dm_conversations/with/"+userID+"/dm_events", params, &res)
+	return res, err
+}
+
+func (app *App) createDM(user, text string, mediaIDs []string) (string, error) {
+	if text == "" && len(mediaIDs) == 0 {
+		return "", errors.New("text or media is required")
+	}
+	userID, err := app.resolveUserID(user)
+	if err != nil {
+		return "", err
+	}
+
+	body := map[string]any{}
+	if text != "" {
+		body["text"] = text
+	}
+	if len(mediaIDs) > 0 {
+		var attachments []map[string]string
+		for _, id := range mediaIDs {
+			attachments = append(attachments, map[string]string{"media_id": id})
+		}
+		body["attachments"] = attachments
+	}
+
+	var res V2DMSendResponse
+	err = app.callPost("https://api.twitter.com/2/dm_conversations/with/"+userID+"/messages", body, &res)
+	if err != nil {
+		return "", err
+	}
+	return res.Data.DMEventID, nil
+}
+
+func showDMEvents(res V2DMEventsResponse, asjson bool, verbose bool) {
+	if len(res.Data) == 0 {
+		return
+	}
+
+	userMap := make(map[string]V2User)
+	for _, u := range res.Includes.Users {
+		userMap[u.ID] = u
+	}
+
+	if asjson {
+		for _, event := range res.Data {
+			json.NewEncoder(os.Stdout).Encode(event)
+			os.Stdout.Sync()
+		}
+	} else if verbose {
+		for i := len(res.Data) - 1; i >= 0; i-- {
+			event := res.Data[i]
+			user := userMap[event.SenderID]
+			color.Set(color.FgHiRed)
+			fmt.Println(user.Username + ": " + user.Name)
+			color.Set(color.Reset)
+			fmt.Println("  " + strings.ReplaceAll(html.UnescapeString(event.Text), "\n", "\n  "))
+			if event.Attachments != nil && len(event.Attachments.MediaKeys) > 0 {
+				fmt.Println("  media: " + strings.Join(event.Attachments.MediaKeys, ", "))
+			}
+			fmt.Println("  " + event.ID)
+			fmt.Println("  " + displayTime.full(event.CreatedAt))
+			fmt.Println()
+		}
+	} else {
+		for i := len(res.Data) - 1; i >= 0; i-- {
+			event := res.Data[i]
+			user := userMap[event.SenderID]
+			color.Set(color.FgHiRed)
+			fmt.Print(user.Username)
+			color.Set(color.Reset)
+			fmt.Print(": ")
+			fmt.Println(html.UnescapeString(event.Text))
+		}
+	}
+}
+
+// newDMEvents returns the events newer than lastID. DM endpoints have no
+// since_id parameter, so polling filters on the client side.
+func newDMEvents(res V2DMEventsResponse, lastID string) V2DMEventsResponse {
+	if lastID == "" {
+		return res
+	}
+	var events []V2DMEvent
+	for _, event := range res.Data {
+		if tweetIDLess(lastID, event.ID) {
+			events = append(events, event)
+		}
+	}
+	res.Data = events
+	return res
+}
+
+func (app *App) showDMs() {
+	if app.delay == 0 {
+		res, err := app.fetchDMEvents(app.count)
+		if err != nil {
+			log.Fatalf("cannot get direct messages: %v", err)
+		}
+		showDMEvents(res, app.asjson, app.verbose)
+		return
+	}
+
+	var lastID string
+	for {
+		res, err := app.fetchDMEvents(app.count)
+		if err != nil {
+			log.Printf("cannot get direct messages: %v", err)
+		} else if res = newDMEvents(res, lastID); len(res.Data) > 0 {
+			showDMEvents(res, app.asjson, app.verbose)
+			for _, event := range res.Data {
+				if tweetIDLess(lastID, event.ID) {
+					lastID = event.ID
+				}
+			}
+		}
+		time.Sleep(app.delay)
+	}
+}
+
+func (app *App) showDMConversation() {
+	res, err := app.fetchDMConversation(app.dmWith, app.count)
+	if err != nil {
+		log.Fatalf("cannot get direct messages: %v", err)
+	}
+	showDMEvents(res, app.asjson, app.verbose)
+}
+
+func (app *App) sendDM() {
+	text := strings.Join(flag.Args(), " ")
+	id, err := app.createDM(app.dmTo, text, app.media)
+	if err != nil {
+		log.Fatalf("cannot send direct message: %v", err)
+	}
+	fmt.Println("sent:", id)
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestNewDMEventsFirstPoll(t *testing.T) {
+	res := V2DMEventsResponse{Data: []V2DMEvent{{ID: "2"}, {ID: "1"}}}
+	if got := newDMEvents(res, ""); len(got.Data) != 2 {
+		t.Errorf("got %d events, want 2", len(got.Data))
+	}
+}
+
+func TestNewDMEventsFiltersSeen(t *testing.T) {
+	res := V2DMEventsResponse{Data: []V2DMEvent{{ID: "100"}, {ID: "99"}, {ID: "98"}}}
+	got := newDMEvents(res, "99")
+	if len(got.Data) != 1 || got.Data[0].ID != "100" {
+		t.Errorf("got %+v, want only event 100", got.Data)
+	}
+}
+
+func TestCreateDMRequiresContent(t *testing.T) {
+	app := &App{}
+	if _, err := app.createDM("alice", "", nil); err == nil {
+		t.Errorf("expected error for empty direct message")
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type V2Entities struct {
+	URLs     []V2URLEntity     `json:"urls,omitempty"`
+	Hashtags []V2TagEntity     `json:"hashtags,omitempty"`
+	Cashtags []V2TagEntity     `json:"cashtags,omitempty"`
+	Mentions []V2MentionEntity `json:"mentions,omitempty"`
+}
+
+type V2URLEntity struct {
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	URL         string `json:"url"`
+	ExpandedURL string `json:"expanded_url,omitempty"`
+	DisplayURL  string `json:"display_url,omitempty"`
+}
+
+type V2TagEntity struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Tag   string `json:"tag"`
+}
+
+type V2MentionEntity struct {
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Username string `json:"username"`
+	ID       string `json:"id,omitempty"`
+}
+
+var (
+	hashtagColor = color.New(color.FgHiCyan)
+	cashtagColor = color.New(color.FgHiGreen)
+	mentionColor = color.New(color.FgHiYellow)
+	linkColor    = color.New(color.FgHiBlue)
+)
+
+// expandURLs replaces the t.co links in text with the URLs they point to.
+func expandURLs(text string, e *V2Entities) string {
+	if e == nil {
+		return text
+	}
+	for _, u := range e.URLs {
+		if u.URL != "" && u.ExpandedURL != "" {
+			text = strings.ReplaceAll(text, u.URL, u.ExpandedURL)
+		}
+	}
+	return text
+}
+
+// tweetEntities returns the entities of tweet and of the tweets it
+// references, which tweetText includes in its text.
+func tweetEntities(tweet V2Tweet, tweetMap map[string]V2Tweet) []*V2Entities {
+	_, e := longText(tweet)
+	entities := []*V2Entities{e}
+	for _, ref := range tweet.ReferencedTweets {
+		if rt, ok := tweetMap[ref.ID]; ok {
+			_, e := longText(rt)
+			entities = append(entities, e)
+		}
+	}
+	return entities
+}
+
+// entityLinks returns the expanded URLs in entities.
+func entityLinks(entities ...*V2Entities) []string {
+	var links []string
+	for _, e := range entities {
+		if e == nil {
+			continue
+		}
+		for _, u := range e.URLs {
+			if u.ExpandedURL != "" {
+				links = append(links, u.ExpandedURL)
+			} else {
+				links = append(links, u.URL)
+			}
+		}
+	}
+	return links
+}
+
+const tagPattern = `[#＃$@＠][\p{L}\p{M}\p{N}_]+`
+
+// highlightEntities colors the hashtags, cashtags and mentions in text that
+// are listed in entities. The expanded URLs are shown by their display URL
+// as OSC 8 hyperlinks when hyperlinks is true. text is expected to have its
+// URLs expanded already.
+func highlightEntities(text string, entities []*V2Entities, hyperlinks bool) string {
+	tags := map[string]*color.Color{}
+	display := map[string]string{}
+	var urls []string
+	for _, e := range entities {
+		if e == nil {
+			continue
+		}
+		for _, h := range e.Hashtags {
+			tags["#"+strings.ToLower(h.Tag)] = hashtagColor
+		}
+		for _, c := range e.Cashtags {
+			tags["$"+strings.ToLower(c.Tag)] = cashtagColor
+		}
+		for _, m := range e.Mentions {
+			tags["@"+strings.ToLower(m.Username)] = mentionColor
+		}
+		for _, u := range e.URLs {
+			if u.ExpandedURL == "" {
+				continue
+			}
+			display[u.ExpandedURL] = u.DisplayURL
+			urls = append(urls, u.ExpandedURL)
+		}
+	}
+
+	// Longer URLs first, so a URL is not cut at a shorter one it starts with.
+	sort.Slice(urls, func(i, j int) bool { return len(urls[i]) > len(urls[j]) })
+	pattern := tagPattern
+	if len(urls) > 0 {
+		quoted := make([]string, len(urls))
+		for i, u := range urls {
+			quoted[i] = regexp.QuoteMeta(u)
+		}
+		pattern = strings.Join(quoted, "|") + "|" + pattern
+	}
+
+	return regexp.MustCompile(pattern).ReplaceAllStringFunc(text, func(s string) string {
+		if d, ok := display[s]; ok {
+			if !hyperlinks {
+				return linkColor.Sprint(s)
+			}
+			if d == "" {
+				d = s
+			}
+			return hyperlink(s, linkColor.Sprint(d))
+		}
+		key := strings.NewReplacer("＃", "#", "＠", "@").Replace(s)
+		if c, ok := tags[strings.ToLower(key)]; ok {
+			return c.Sprint(s)
+		}
+		return s
+	})
+}
+
+// hyperlink wraps text in an OSC 8 escape sequence linking to uri.
+func hyperlink(uri string, text string) string {
+	return "\x1b]8;;" + uri + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// supportsHyperlinks reports whether the terminal shows OSC 8 hyperlinks.
+// FORCE_HYPERLINK=1 or 0 overrides the detection.
+func supportsHyperlinks() bool {
+	if v, ok := os.LookupEnv("FORCE_HYPERLINK"); ok {
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	if color.NoColor {
+		return false
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return true
+	}
+	if v, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+	if os.Getenv("WT_SESSION") != "" || os.Getenv("KITTY_WINDOW_ID") != "" {
+		return true
+	}
+	term := os.Getenv("TERM")
+	for _, t := range []string{"kitty", "alacritty", "foot", "ghostty", "wezterm"} {
+		if strings.Contains(term, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderText prepares text of a tweet for the terminal, highlighting its
+// entities when colors are enabled.
+func renderText(text string, entities []*V2Entities) string {
+	if color.NoColor {
+		return text
+	}
+	return highlightEntities(text, entities, supportsHyperlinks())
+}
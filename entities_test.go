@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func testEntities() *V2Entities {
+	return &V2Entities{
+		URLs:     []V2URLEntity{{URL: "https://t.co/abc", ExpandedURL: "https://go.dev/doc/", DisplayURL: "go.dev/doc/"}},
+		Hashtags: []V2TagEntity{{Tag: "golang"}},
+		Cashtags: []V2TagEntity{{Tag: "GOOG"}},
+		Mentions: []V2MentionEntity{{Username: "alice"}},
+	}
+}
+
+func TestExpandURLs(t *testing.T) {
+	got := expandURLs("read https://t.co/abc now", testEntities())
+	if want := "read https://go.dev/doc/ now"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := expandURLs("https://t.co/abc", nil); got != "https://t.co/abc" {
+		t.Errorf("got %q without entities", got)
+	}
+}
+
+func TestTweetTextExpandsURLs(t *testing.T) {
+	tweet := V2Tweet{
+		Text:             "see https://t.co/abc",
+		Entities:         testEntities(),
+		ReferencedTweets: []V2ReferencedTweet{{Type: "quoted", ID: "2"}},
+	}
+	tweetMap := map[string]V2Tweet{"2": {ID: "2", Text: "https://t.co/xyz", Entities: &V2Entities{
+		URLs: []V2URLEntity{{URL: "https://t.co/xyz", ExpandedURL: "https://example.com/"}},
+	}}}
+	want := "see https://go.dev/doc/\n  > https://example.com/"
+	if got := tweetText(tweet, tweetMap, nil); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHighlightEntities(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	entities := []*V2Entities{testEntities()}
+	text := "@Alice #golang $GOOG #other @bob $5 https://go.dev/doc/"
+
+	got := highlightEntities(text, entities, false)
+	want := mentionColor.Sprint("@Alice") + " " + hashtagColor.Sprint("#golang") + " " +
+		cashtagColor.Sprint("$GOOG") + " #other @bob $5 " + linkColor.Sprint("https://go.dev/doc/")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = highlightEntities("https://go.dev/doc/", entities, true)
+	want = "\x1b]8;;https://go.dev/doc/\x1b\\" + linkColor.Sprint("go.dev/doc/") + "\x1b]8;;\x1b\\"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSupportsHyperlinksOverride(t *testing.T) {
+	t.Setenv("FORCE_HYPERLINK", "1")
+	if !supportsHyperlinks() {
+		t.Error("FORCE_HYPERLINK=1 should enable hyperlinks")
+	}
+	t.Setenv("FORCE_HYPERLINK", "0")
+	if supportsHyperlinks() {
+		t.Error("FORCE_HYPERLINK=0 should disable hyperlinks")
+	}
+}
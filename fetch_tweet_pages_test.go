@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testApp() *App {
+	return &App{
+		config: Config{
+			Token: OAuth2Token{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour)},
+		},
+	}
+}
+
+func TestFetchTweetPagesFollowsNextToken(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		page := r.URL.Query().Get("pagination_token")
+		var res V2TweetsResponse
+		for i := range 10 {
+			res.Data = append(res.Data, V2Tweet{ID: fmt.Sprintf("%s%d", page, i)})
+		}
+		if page == "" {
+			res.Meta.NextToken = "p2"
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer ts.Close()
+
+	res, err := testApp().fetchTweetPages(ts.URL, map[string]string{}, "15", pageOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	if len(res.Data) != 15 || res.Meta.ResultCount != 15 {
+		t.Errorf("got %d tweets, want 15", len(res.Data))
+	}
+}
+
+func TestFetchTweetPagesMergesIncludes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var res V2TweetsResponse
+		if r.URL.Query().Get("pagination_token") == "" {
+			res.Data = []V2Tweet{{ID: "2", AuthorID: "10"}}
+			res.Includes.Users = []V2User{{ID: "10", Username: "alice"}}
+			res.Meta.NextToken = "p2"
+		} else {
+			res.Data = []V2Tweet{{ID: "1", Attachments: &V2Attachments{MediaKeys: []string{"3_1"}, PollIDs: []string{"p1"}}}}
+			res.Includes.Media = []V2Media{{MediaKey: "3_1", Type: "photo", URL: "https://pbs.twimg.com/media/a.jpg"}}
+			res.Includes.Polls = []V2Poll{{ID: "p1"}}
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer ts.Close()
+
+	res, err := testApp().fetchTweetPages(ts.URL, map[string]string{}, "20", pageOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Includes.Users) != 1 || len(res.Includes.Polls) != 1 {
+		t.Errorf("includes not merged: %+v", res.Includes)
+	}
+	if len(res.Includes.Media) != 1 || res.Includes.Media[0].MediaKey != "3_1" {
+		t.Errorf("media of the second page lost: %+v", res.Includes.Media)
+	}
+}
+
+func TestFetchTweetPagesSinglePageWithoutCount(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Query().Get("max_results") != "" {
+			t.Errorf("max_results should not be set without count")
+		}
+		json.NewEncoder(w).Encode(V2TweetsResponse{
+			Data: []V2Tweet{{ID: "1"}},
+			Meta: V2Meta{NextToken: "more"},
+		})
+	}))
+	defer ts.Close()
+
+	if _, err := testApp().fetchTweetPages(ts.URL, map[string]string{}, "", pageOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("got %d requests, want 1", calls)
+	}
+}
+
+func TestFetchTweetPagesOptions(t *testing.T) {
+	var sizes []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sizes = append(sizes, r.URL.Query().Get("max_results"))
+		res := V2TweetsResponse{Data: make([]V2Tweet, 500)}
+		if r.URL.Query().Get("next_token") == "" {
+			res.Meta.NextToken = "p2"
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer ts.Close()
+
+	opts := pageOptions{TokenParam: "next_token", MaxResults: 500}
+	res, err := testApp().fetchTweetPages(ts.URL, map[string]string{}, "700", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sizes) != 2 || sizes[0] != "500" || sizes[1] != "200" {
+		t.Errorf("got page sizes %v, want [500 200]", sizes)
+	}
+	if len(res.Data) != 700 {
+		t.Errorf("got %d tweets, want 700", len(res.Data))
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// tweetView is the data given to -format templates.
+type tweetView struct {
+	ID         string
+	Text       string
+	CreatedAt  time.Time
+	URL        string
+	Author     V2User
+	Metrics    V2TweetMetrics
+	NonPublic  *V2NonPublicMetrics
+	Organic    *V2OrganicMetrics
+	Referenced []referencedView
+	Media      []V2Media
+	Tweet      V2Tweet
+}
+
+type referencedView struct {
+	Type   string
+	ID     string
+	Text   string
+	URL    string
+	Author V2User
+}
+
+func tweetURL(username string, id string) string {
+	if username == "" {
+		username = "i/web"
+	}
+	return "https://x.com/" + username + "/status/" + id
+}
+
+// newTweetViews joins the tweets in res with their authors and referenced
+// tweets, in the order they are displayed.
+func newTweetViews(res V2TweetsResponse) []tweetView {
+	userMap := make(map[string]V2User)
+	for _, u := range res.Includes.Users {
+		userMap[u.ID] = u
+	}
+	tweetMap := make(map[string]V2Tweet)
+	for _, t := range res.Includes.Tweets {
+		tweetMap[t.ID] = t
+	}
+	mediaMap := make(map[string]V2Media)
+	for _, m := range res.Includes.Media {
+		mediaMap[m.MediaKey] = m
+	}
+
+	views := make([]tweetView, 0, len(res.Data))
+	for i := len(res.Data) - 1; i >= 0; i-- {
+		tweet := res.Data[i]
+		author := userMap[tweet.AuthorID]
+		v := tweetView{
+			ID:     tweet.ID,
+			Text:   html.UnescapeString(tweetText(tweet, tweetMap, userMap)),
+			URL:    tweetURL(author.Username, tweet.ID),
+			Author: author,
+			Tweet:  tweet,
+		}
+		v.CreatedAt, _ = time.Parse(time.RFC3339, tweet.CreatedAt)
+		if tweet.PublicMetrics != nil {
+			v.Metrics = *tweet.PublicMetrics
+		}
+		v.NonPublic = tweet.NonPublicMetrics
+		v.Organic = tweet.OrganicMetrics
+		for _, ref := range tweet.ReferencedTweets {
+			rv := referencedView{Type: ref.Type, ID: ref.ID}
+			if rt, ok := tweetMap[ref.ID]; ok {
+				rv.Text = html.UnescapeString(fullText(rt))
+				rv.Author = userMap[rt.AuthorID]
+			}
+			rv.URL = tweetURL(rv.Author.Username, ref.ID)
+			v.Referenced = append(v.Referenced, rv)
+		}
+		if tweet.Attachments != nil {
+			for _, key := range tweet.Attachments.MediaKeys {
+				if m, ok := mediaMap[key]; ok {
+					v.Media = append(v.Media, m)
+				}
+			}
+		}
+		views = append(views, v)
+	}
+	return views
+}
+
+// truncate shortens s to n characters, ending with "…" when cut.
+func truncate(n int, s string) string {
+	r := []rune(s)
+	if n <= 0 || len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+var templateColors = map[string]color.Attribute{
+	"black":   color.FgHiBlack,
+	"red":     color.FgHiRed,
+	"green":   color.FgHiGreen,
+	"yellow":  color.FgHiYellow,
+	"blue":    color.FgHiBlue,
+	"magenta": color.FgHiMagenta,
+	"cyan":    color.FgHiCyan,
+	"white":   color.FgHiWhite,
+	"bold":    color.Bold,
+}
+
+var templateFuncs = template.FuncMap{
+	"reltime": func(t time.Time) string {
+		return relativeTime(t, time.Now(), " ago")
+	},
+	"localtime": func(t time.Time) string {
+		return strftime(t.In(displayTime.Location), displayTime.Format)
+	},
+	"strftime": func(format string, t time.Time) string {
+		return strftime(t.In(displayTime.Location), format)
+	},
+	"truncate": truncate,
+	"color": func(name string, s string) (string, error) {
+		attr, ok := templateColors[name]
+		if !ok {
+			return "", fmt.Errorf("unknown color %q", name)
+		}
+		return color.New(attr).Sprint(s), nil
+	},
+	"url": tweetURL,
+	"oneline": func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	},
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// parseTweetTemplate parses format, which is either the name of a template
+// in templates or a template itself.
+func parseTweetTemplate(format string, templates map[string]string) (*template.Template, error) {
+	if t, ok := templates[format]; ok {
+		format = t
+	} else if !strings.Contains(format, "{{") {
+		return nil, fmt.Errorf("no template named %q in the configuration", format)
+	}
+	return template.New("format").Funcs(templateFuncs).Parse(format)
+}
+
+// showTemplateTweets writes every tweet in res with tmpl, each followed by a
+// newline.
+func showTemplateTweets(w io.Writer, res V2TweetsResponse, tmpl *template.Template) error {
+	for _, v := range newTweetViews(res) {
+		if err := tmpl.Execute(w, v); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// privateMetricsField matches the template fields that can show the
+// non-public and organic metrics.
+var privateMetricsField = regexp.MustCompile(`\.(NonPublic|Organic|Tweet)\b`)
+
+// templateUsesPrivateMetrics reports whether tmpl shows the non-public or
+// organic metrics, so they are only fetched when needed.
+func templateUsesPrivateMetrics(tmpl *template.Template) bool {
+	return tmpl.Tree != nil && privateMetricsField.MatchString(tmpl.Tree.Root.String())
+}
+
+// showsPrivateMetrics reports whether tweets are shown with their non-public
+// and organic metrics.
+func (app *App) showsPrivateMetrics() bool {
+	if app.tmpl != nil {
+		return templateUsesPrivateMetrics(app.tmpl)
+	}
+	return app.verbose || app.asjson || app.output != ""
+}
+
+func (app *App) showTweets(res V2TweetsResponse) {
+	jsonOutput := app.asjson || app.output == "json"
+	if len(res.Data) > 0 && app.showsPrivateMetrics() {
+		app.fetchOwnMetrics(&res)
+	}
+	if app.sort != "" {
+		if err := sortTweets(res.Data, app.sort); err != nil {
+			log.Fatal(err)
+		}
+		// Except for JSON, tweets are shown from the last one, so the top
+		// tweet goes last to be shown first.
+		if !jsonOutput {
+			slices.Reverse(res.Data)
+		}
+	}
+
+	if app.tmpl != nil {
+		if err := showTemplateTweets(os.Stdout, res, app.tmpl); err != nil {
+			log.Fatalf("cannot format tweets: %v", err)
+		}
+		return
+	}
+	if isTweetOutputFormat(app.output) {
+		if len(res.Data) == 0 && app.wroteHeader {
+			return
+		}
+		if err := writeTweetTable(os.Stdout, app.output, newTweetViews(res), !app.wroteHeader); err != nil {
+			log.Fatalf("cannot write tweets: %v", err)
+		}
+		app.wroteHeader = true
+		return
+	}
+	if jsonOutput && !app.asjson {
+		if err := writeJSONTweets(os.Stdout, res); err != nil {
+			log.Fatalf("cannot write tweets: %v", err)
+		}
+		return
+	}
+	showV2Tweets(res, app.asjson, app.verbose)
+}
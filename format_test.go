@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestShowTemplateTweets(t *testing.T) {
+	res := V2TweetsResponse{
+		Data: []V2Tweet{
+			{ID: "2", Text: "newer &amp; quoting", AuthorID: "10", ReferencedTweets: []V2ReferencedTweet{{Type: "quoted", ID: "9"}}},
+			{ID: "1", Text: "older", AuthorID: "10", PublicMetrics: &V2TweetMetrics{LikeCount: 3}},
+		},
+	}
+	res.Includes.Users = []V2User{{ID: "10", Username: "alice"}, {ID: "11", Username: "bob"}}
+	res.Includes.Tweets = []V2Tweet{{ID: "9", Text: "quoted", AuthorID: "11"}}
+
+	tmpl, err := parseTweetTemplate("{{.Author.Username}} {{.ID}} {{.Metrics.LikeCount}} {{range .Referenced}}{{.Type}}:{{.URL}}{{end}}", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := showTemplateTweets(&buf, res, tmpl); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "alice 1 3 \nalice 2 0 quoted:https://x.com/bob/status/9\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseTweetTemplateNamed(t *testing.T) {
+	templates := map[string]string{"short": "{{truncate 5 .Text}}"}
+	tmpl, err := parseTweetTemplate("short", templates)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, tweetView{Text: "hello world"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := buf.String(); got != "hell…" {
+		t.Errorf("got %q, want %q", got, "hell…")
+	}
+
+	if _, err := parseTweetTemplate("long", templates); err == nil {
+		t.Error("expected error for unknown template name")
+	}
+	if _, err := parseTweetTemplate("{{.Text", nil); err == nil {
+		t.Error("expected error for broken template")
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now.Add(-30 * time.Second), "30s ago"},
+		{now.Add(-5 * time.Minute), "5m ago"},
+		{now.Add(-3 * time.Hour), "3h ago"},
+		{now.Add(-2 * 24 * time.Hour), "2d ago"},
+		{time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), "Mar 1"},
+		{time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), "Mar 1, 2025"},
+		{now.Add(time.Minute), "now"},
+		{time.Time{}, ""},
+	}
+	for _, tt := range tests {
+		if got := relativeTime(tt.t, now, " ago"); got != tt.want {
+			t.Errorf("relativeTime(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
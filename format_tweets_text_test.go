@@ -70,3 +70,19 @@ func TestFormatTweetsTextExpandsRetweet(t *testing.T) {
 		t.Errorf("retweet not expanded: %q", formatTweetsText(res))
 	}
 }
+
+func TestFormatTweetsTextRetweetAttribution(t *testing.T) {
+	res := V2TweetsResponse{
+		Data: []V2Tweet{{
+			ID: "1", Text: "RT @bob: trunc…", AuthorID: "u1",
+			ReferencedTweets: []V2ReferencedTweet{{Type: "retweeted", ID: "src"}},
+		}},
+		Includes: V2Includes{
+			Users:  []V2User{{ID: "u1", Username: "alice"}, {ID: "u2", Username: "bob"}},
+			Tweets: []V2Tweet{{ID: "src", Text: "trunc…", AuthorID: "u2", NoteTweet: &V2NoteTweet{Text: "full text"}}},
+		},
+	}
+	if got := formatTweetsText(res); !strings.Contains(got, "RT @bob: full text") {
+		t.Errorf("retweet not attributed: %q", got)
+	}
+}
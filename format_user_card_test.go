@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatUserCardMinimal(t *testing.T) {
+	if got := formatUserCard(V2User{ID: "1", Username: "alice"}, nil); got != "" {
+		t.Errorf("got %q, want empty card", got)
+	}
+}
+
+func TestFormatUserCardFull(t *testing.T) {
+	user := V2User{
+		ID:            "1",
+		Username:      "alice",
+		Description:   "hello &amp; welcome",
+		Location:      "Tokyo",
+		URL:           "https://example.com",
+		CreatedAt:     "2010-01-02T03:04:05.000Z",
+		Protected:     true,
+		Verified:      true,
+		VerifiedType:  "blue",
+		PinnedTweetID: "99",
+		PublicMetrics: &V2UserMetrics{FollowersCount: 20, FollowingCount: 10, TweetCount: 30, ListedCount: 4},
+	}
+	tm := map[string]V2Tweet{"99": {ID: "99", Text: "pinned body"}}
+	want := strings.Join([]string{
+		"protected, verified (blue)",
+		"hello & welcome",
+		"Location: Tokyo",
+		"URL: https://example.com",
+		"Joined: 2010-01-02T03:04:05.000Z",
+		"Following: 10, Followers: 20, Tweets: 30, Listed: 4",
+		"Pinned [99]: pinned body",
+	}, "\n")
+	if got := formatUserCard(user, tm); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatUserCardPinnedNoteTweet(t *testing.T) {
+	tm := map[string]V2Tweet{"99": {
+		ID:        "99",
+		Text:      "short…",
+		NoteTweet: &V2NoteTweet{Text: "the whole &amp; long post"},
+	}}
+	got := formatUserCard(V2User{PinnedTweetID: "99"}, tm)
+	if want := "Pinned [99]: the whole & long post"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatUserCardPinnedNotIncluded(t *testing.T) {
+	got := formatUserCard(V2User{PinnedTweetID: "99"}, nil)
+	if got != "Pinned: 99" {
+		t.Errorf("got %q, want %q", got, "Pinned: 99")
+	}
+}
+
+func TestFormatUsersTextEmpty(t *testing.T) {
+	if got := formatUsersText(V2UsersResponse{}); got != "No users found." {
+		t.Errorf("got %q, want %q", got, "No users found.")
+	}
+}
+
+func TestFormatUsersTextReportsErrors(t *testing.T) {
+	res := V2UsersResponse{
+		Data:   []V2User{{ID: "1", Name: "Alice", Username: "alice"}},
+		Errors: []V2Error{{Value: "nobody", Detail: "Could not find user with usernames: [nobody]."}},
+	}
+	got := formatUsersText(res)
+	if !strings.HasPrefix(got, "@alice (Alice) [1]:") {
+		t.Errorf("missing header: %q", got)
+	}
+	if !strings.Contains(got, "nobody: Could not find user") {
+		t.Errorf("missing error: %q", got)
+	}
+}
@@ -2,10 +2,15 @@ module github.com/mattn/twty
 
 go 1.26.1
 
-require github.com/fatih/color v1.19.0
+require (
+	github.com/clipperhouse/uax29/v2 v2.2.0
+	github.com/fatih/color v1.19.0
+	github.com/mattn/go-runewidth v0.0.30
+	golang.org/x/term v0.46.0
+)
 
 require (
 	github.com/mattn/go-colorable v0.1.14 // indirect
 	github.com/mattn/go-isatty v0.0.22 // indirect
-	golang.org/x/sys v0.44.0 // indirect
+	golang.org/x/sys v0.48.0 // indirect
 )
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/fatih/color"
+)
+
+func (app *App) createList(name, description string, private bool) (string, error) {
+	body := map[string]any{
+		"name":    name,
+		"private": private,
+	}
+	if description != "" {
+		body["description"] = description
+	}
+	var res V2ListResponse
+	if err := app.callPost("https://api.twitter.com/2/lists", body, &res); err != nil {
+		return "", err
+	}
+	return res.Data.ID, nil
+}
+
+func (app *App) updateList(list string, body map[string]any) (string, error) {
+	listID, err := app.resolveListID(list)
+	if err != nil {
+		return "", err
+	}
+	return listID, app.callPut("https://api.twitter.com/2/lists/"+listID, body, nil)
+}
+
+func (app *App) deleteList(list string) (string, error) {
+	listID, err := app.resolveListID(list)
+	if err != nil {
+		return "", err
+	}
+	return listID, app.callDelete("https://api.twitter.com/2/lists/"+listID, nil)
+}
+
+func (app *App) addListMember(list, user string) error {
+	listID, err := app.resolveListID(list)
+	if err != nil {
+		return err
+	}
+	userID, err := app.resolveUserID(user)
+	if err != nil {
+		return err
+	}
+	body := map[string]string{
+		"user_id": userID,
+	}
+	return app.callPost("https://api.twitter.com/2/lists/"+listID+"/members", body, nil)
+}
+
+func (app *App) removeListMember(list, user string) error {
+	listID, err := app.resolveListID(list)
+	if err != nil {
+		return err
+	}
+	userID, err := app.resolveUserID(user)
+	if err != nil {
+		return err
+	}
+	return app.callDelete("https://api.twitter.com/2/lists/"+listID+"/members/"+userID, nil)
+}
+
+// setListRelation follows or pins a list (relation is "followed_lists" or
+// "pinned_lists"), or undoes it when enable is false.
+func (app *App) setListRelation(list, relation string, enable bool) error {
+	myID, err := app.getMyID()
+	if err != nil {
+		return err
+	}
+	listID, err := app.resolveListID(list)
+	if err != nil {
+		return err
+	}
+	uri := "https://api.twitter.com/2/users/" + myID + "/" + relation
+	if !enable {
+		return app.callDelete(uri+"/"+listID, nil)
+	}
+	body := map[string]string{
+		"list_id": listID,
+	}
+	return app.callPost(uri, body, nil)
+}
+
+func (app *App) fetchListUsers(list, relation string) ([]V2User, error) {
+	listID, err := app.resolveListID(list)
+	if err != nil {
+		return nil, err
+	}
+	return app.fetchUsers("https://api.twitter.com/2/lists/"+listID+"/"+relation, 100, 0)
+}
+
+func (app *App) fetchMyLists() ([]V2List, error) {
+	myID, err := app.getMyID()
+	if err != nil {
+		return nil, err
+	}
+	return app.fetchLists("https://api.twitter.com/2/users/" + myID + "/owned_lists")
+}
+
+func showV2Lists(lists []V2List, asjson bool, verbose bool) {
+	if asjson {
+		for _, list := range lists {
+			json.NewEncoder(os.Stdout).Encode(list)
+			os.Stdout.Sync()
+		}
+	} else if verbose {
+		for _, list := range lists {
+			color.Set(color.FgHiRed)
+			fmt.Println(list.Name)
+			color.Set(color.Reset)
+			if list.Description != "" {
+				fmt.Println("  " + list.Description)
+			}
+			fmt.Println("  " + list.ID)
+			visibility := "public"
+			if list.Private {
+				visibility = "private"
+			}
+			fmt.Printf("  %s, %d members, %d followers\n", visibility, list.MemberCount, list.FollowerCount)
+			fmt.Println()
+		}
+	} else {
+		for _, list := range lists {
+			color.Set(color.FgHiRed)
+			fmt.Print(list.Name)
+			color.Set(color.Reset)
+			fmt.Println(": " + list.ID)
+		}
+	}
+}
+
+func (app *App) listCommand() {
+	switch {
+	case app.listCreate != "":
+		id, err := app.createList(app.listCreate, app.listDescription, app.listPrivate)
+		if err != nil {
+			log.Fatalf("cannot create list: %v", err)
+		}
+		fmt.Println("created:", id)
+	case app.listUpdate != "":
+		body := map[string]any{}
+		if app.listName != "" {
+			body["name"] = app.listName
+		}
+		if app.listDescription != "" {
+			body["description"] = app.listDescription
+		}
+		if isFlagSet("list-private") {
+			body["private"] = app.listPrivate
+		}
+		if len(body) == 0 {
+			log.Fatal("nothing to update: specify -list-name, -list-description or -list-private")
+		}
+		id, err := app.updateList(app.listUpdate, body)
+		if err != nil {
+			log.Fatalf("cannot update list: %v", err)
+		}
+		fmt.Println("updated:", id)
+	case app.listDelete != "":
+		id, err := app.deleteList(app.listDelete)
+		if err != nil {
+			log.Fatalf("cannot delete list: %v", err)
+		}
+		fmt.Println("deleted:", id)
+	case app.listAdd != "":
+		if app.list == "" {
+			log.Fatal("specify the list with -l")
+		}
+		if err := app.addListMember(app.list, app.listAdd); err != nil {
+			log.Fatalf("cannot add list member: %v", err)
+		}
+		fmt.Println("added:", app.listAdd)
+	case app.listRemove != "":
+		if app.list == "" {
+			log.Fatal("specify the list with -l")
+		}
+		if err := app.removeListMember(app.list, app.listRemove); err != nil {
+			log.Fatalf("cannot remove list member: %v", err)
+		}
+		fmt.Println("removed:", app.listRemove)
+	case app.listFollow != "":
+		if err := app.setListRelation(app.listFollow, "followed_lists", true); err != nil {
+			log.Fatalf("cannot follow list: %v", err)
+		}
+		fmt.Println("followed:", app.listFollow)
+	case app.listUnfollow != "":
+		if err := app.setListRelation(app.listUnfollow, "followed_lists", false); err != nil {
+			log.Fatalf("cannot unfollow list: %v", err)
+		}
+		fmt.Println("unfollowed:", app.listUnfollow)
+	case app.listPin != "":
+		if err := app.setListRelation(app.listPin, "pinned_lists", true); err != nil {
+			log.Fatalf("cannot pin list: %v", err)
+		}
+		fmt.Println("pinned:", app.listPin)
+	case app.listUnpin != "":
+		if err := app.setListRelation(app.listUnpin, "pinned_lists", false); err != nil {
+			log.Fatalf("cannot unpin list: %v", err)
+		}
+		fmt.Println("unpinned:", app.listUnpin)
+	case app.listMembers || app.listFollowers:
+		if app.list == "" {
+			log.Fatal("specify the list with -l")
+		}
+		relation := "members"
+		if app.listFollowers {
+			relation = "followers"
+		}
+		users, err := app.fetchListUsers(app.list, relation)
+		if err != nil {
+			log.Fatalf("cannot get list %s: %v", relation, err)
+		}
+		showV2Users(users, app.output, app.asjson, app.verbose)
+	case app.lists:
+		lists, err := app.fetchMyLists()
+		if err != nil {
+			log.Fatalf("cannot get lists: %v", err)
+		}
+		showV2Lists(lists, app.asjson, app.verbose)
+	}
+}
+
+func (app *App) isListCommand() bool {
+	return app.listCreate != "" || app.listUpdate != "" || app.listDelete != "" ||
+		app.listAdd != "" || app.listRemove != "" ||
+		app.listFollow != "" || app.listUnfollow != "" ||
+		app.listPin != "" || app.listUnpin != "" ||
+		app.listMembers || app.listFollowers || app.lists
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+const maxLookupIDs = 100
+
+// minTweetIDLength is the length of tweet IDs since 2010, when they became
+// snowflake IDs. Shorter numbers in free-form input are taken for counts,
+// years and the like rather than tweet IDs.
+const minTweetIDLength = 15
+
+// parseTweetIDs extracts tweet IDs from input. CSV with an "id" column, like
+// the output of -o csv, is read by that column. Otherwise status URLs and
+// numbers long enough to be tweet IDs are taken from free-form text, and
+// other tokens, like a header row or metrics, are skipped.
+func parseTweetIDs(s string) []string {
+	tokens, ok := csvIDColumn(s)
+	if !ok {
+		tokens = strings.FieldsFunc(s, func(r rune) bool {
+			switch r {
+			case ',', ';', '"', '\'', ' ', '\t', '\r', '\n':
+				return true
+			}
+			return false
+		})
+	}
+
+	seen := make(map[string]bool)
+	var ids []string
+	for _, f := range tokens {
+		id, err := parseTweetID(f)
+		if err != nil || seen[id] {
+			continue
+		}
+		if !ok && !strings.Contains(f, "/") && len(id) < minTweetIDLength {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// csvIDColumn returns the values of the "id" column when s is CSV with a
+// header row naming one.
+func csvIDColumn(s string) ([]string, bool) {
+	r := csv.NewReader(strings.NewReader(s))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil || len(records) == 0 {
+		return nil, false
+	}
+	col := slices.Index(records[0], "id")
+	if col < 0 || len(records[0]) < 2 {
+		return nil, false
+	}
+	var values []string
+	for _, record := range records[1:] {
+		if col < len(record) {
+			values = append(values, record[col])
+		}
+	}
+	return values, true
+}
+
+func (app *App) lookupTweets(ids []string) (V2TweetsResponse, error) {
+	var res V2TweetsResponse
+	for len(ids) > 0 {
+		n := min(len(ids), maxLookupIDs)
+		params := v2TweetFields()
+		params["tweet.fields"] += ",withheld"
+		params["ids"] = strings.Join(ids[:n], ",")
+
+		var page V2TweetsResponse
+		if err := app.callGet("https://api.twitter.com/2/tweets", params, &page); err != nil {
+			return V2TweetsResponse{}, err
+		}
+		res.Data = append(res.Data, page.Data...)
+		res.Includes.add(page.Includes)
+		res.Errors = append(res.Errors, page.Errors...)
+		ids = ids[n:]
+	}
+	res.Meta.ResultCount = len(res.Data)
+	return res, nil
+}
+
+// lookupProblems describes the requested IDs that did not come back as
+// viewable tweets: those reported as errors, those silently missing, and
+// those withheld in some countries. Errors about expansions, like a deleted
+// referenced tweet, are not about the requested IDs and are left out.
+func lookupProblems(ids []string, res V2TweetsResponse) []string {
+	requested := make(map[string]bool)
+	for _, id := range ids {
+		requested[id] = true
+	}
+	found := make(map[string]bool)
+	for _, t := range res.Data {
+		found[t.ID] = true
+	}
+	reported := make(map[string]bool)
+
+	var problems []string
+	for _, e := range res.Errors {
+		id := e.ResourceID
+		if id == "" {
+			id = e.Value
+		}
+		if e.Parameter != "ids" && !requested[id] || found[id] || reported[id] {
+			continue
+		}
+		reported[id] = true
+		problems = append(problems, fmt.Sprintf("missing: %s: %s", id, e.Detail))
+	}
+	for _, id := range ids {
+		if !found[id] && !reported[id] {
+			problems = append(problems, "missing: "+id)
+		}
+	}
+	for _, t := range res.Data {
+		if w := t.Withheld; w != nil {
+			reason := "in " + strings.Join(w.CountryCodes, ",")
+			if w.Copyright {
+				reason = "for copyright"
+			}
+			problems = append(problems, fmt.Sprintf("withheld: %s: %s", t.ID, reason))
+		}
+	}
+	return problems
+}
+
+// orderTweets puts tweets in the order their IDs were requested, newest
+// position first, so showV2Tweets prints them in the requested order.
+func orderTweets(ids []string, tweets []V2Tweet) []V2Tweet {
+	byID := make(map[string]V2Tweet)
+	for _, t := range tweets {
+		byID[t.ID] = t
+	}
+	var ordered []V2Tweet
+	for i := len(ids) - 1; i >= 0; i-- {
+		if t, ok := byID[ids[i]]; ok {
+			ordered = append(ordered, t)
+		}
+	}
+	return ordered
+}
+
+func (app *App) showLookup() {
+	input := strings.Join(flag.Args(), " ")
+	if input == "" {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatalf("cannot read tweet IDs: %v", err)
+		}
+		input = string(b)
+	}
+	ids := parseTweetIDs(input)
+	if len(ids) == 0 {
+		log.Fatal("no tweet IDs given")
+	}
+
+	res, err := app.lookupTweets(ids)
+	if err != nil {
+		log.Fatalf("cannot get tweets: %v", err)
+	}
+	res.Data = orderTweets(ids, res.Data)
+	app.showTweets(res)
+	for _, p := range lookupProblems(ids, res) {
+		fmt.Fprintln(os.Stderr, p)
+	}
+}
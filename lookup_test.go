@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTweetIDsCSV(t *testing.T) {
+	in := "id,note\n\"123\",first\n456,\"second\"\nhttps://x.com/alice/status/789,third\n123,duplicate\n"
+	want := []string{"123", "456", "789"}
+	if got := parseTweetIDs(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseTweetIDsCSVMetrics(t *testing.T) {
+	in := "id,created_at,like_count,impression_count\n1234567890123456789,2024-01-02T03:04:05Z,42,15000\n"
+	want := []string{"1234567890123456789"}
+	if got := parseTweetIDs(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseTweetIDsFreeForm(t *testing.T) {
+	in := "1234567890123456789,2024,42,15000 https://x.com/alice/status/20\n"
+	want := []string{"1234567890123456789", "20"}
+	if got := parseTweetIDs(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseTweetIDsEmpty(t *testing.T) {
+	if got := parseTweetIDs("id\n"); len(got) != 0 {
+		t.Errorf("got %v, want none", got)
+	}
+}
+
+func TestLookupProblems(t *testing.T) {
+	res := V2TweetsResponse{
+		Data: []V2Tweet{
+			{ID: "1"},
+			{ID: "2", Withheld: &V2Withheld{CountryCodes: []string{"DE", "FR"}}},
+		},
+		Errors: []V2Error{
+			{ResourceID: "3", Parameter: "ids", Detail: "Could not find tweet with ids: [3]."},
+			{ResourceID: "8", Parameter: "referenced_tweets.id", Detail: "Could not find tweet with referenced_tweets.id: [8]."},
+			{ResourceID: "20", Parameter: "author_id", Detail: "User has been suspended: [20]."},
+		},
+	}
+	got := lookupProblems([]string{"1", "2", "3", "4"}, res)
+	want := []string{
+		"missing: 3: Could not find tweet with ids: [3].",
+		"missing: 4",
+		"withheld: 2: in DE,FR",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestOrderTweets(t *testing.T) {
+	tweets := []V2Tweet{{ID: "1"}, {ID: "3"}, {ID: "2"}}
+	got := orderTweets([]string{"3", "1", "9", "2"}, tweets)
+	var ids []string
+	for _, t := range got {
+		ids = append(ids, t.ID)
+	}
+	want := []string{"2", "1", "3"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("got %v, want %v", ids, want)
+	}
+}
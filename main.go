@@ -1,11 +1,13 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -21,8 +23,10 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/fatih/color"
@@ -45,7 +49,7 @@ const (
 	authorizationURL    = "https://twitter.com/i/oauth2/authorize"
 	tokenURL            = "https://api.twitter.com/2/oauth2/token"
 	callbackPort        = 8989
-	oauthScopes         = "tweet.read tweet.write users.read like.read like.write list.read offline.access"
+	oauthScopes         = "tweet.read tweet.write tweet.moderate.write users.read like.read like.write list.read list.write mute.read mute.write block.read block.write dm.read dm.write space.read offline.access"
 )
 
 type V2Tweet struct {
@@ -53,7 +57,74 @@ type V2Tweet struct {
 	Text             string              `json:"text"`
 	AuthorID         string              `json:"author_id"`
 	CreatedAt        string              `json:"created_at"`
+	ConversationID   string              `json:"conversation_id,omitempty"`
+	InReplyToUserID  string              `json:"in_reply_to_user_id,omitempty"`
 	ReferencedTweets []V2ReferencedTweet `json:"referenced_tweets,omitempty"`
+	Attachments      *V2Attachments      `json:"attachments,omitempty"`
+	EditHistory      []string            `json:"edit_history_tweet_ids,omitempty"`
+	Withheld         *V2Withheld         `json:"withheld,omitempty"`
+	PublicMetrics    *V2TweetMetrics     `json:"public_metrics,omitempty"`
+	Entities         *V2Entities         `json:"entities,omitempty"`
+	NonPublicMetrics *V2NonPublicMetrics `json:"non_public_metrics,omitempty"`
+	OrganicMetrics   *V2OrganicMetrics   `json:"organic_metrics,omitempty"`
+	NoteTweet        *V2NoteTweet        `json:"note_tweet,omitempty"`
+}
+
+// V2NoteTweet holds the full text of a post longer than 280 characters.
+type V2NoteTweet struct {
+	Text     string      `json:"text"`
+	Entities *V2Entities `json:"entities,omitempty"`
+}
+
+type V2TweetMetrics struct {
+	RetweetCount    int `json:"retweet_count"`
+	ReplyCount      int `json:"reply_count"`
+	LikeCount       int `json:"like_count"`
+	QuoteCount      int `json:"quote_count"`
+	BookmarkCount   int `json:"bookmark_count"`
+	ImpressionCount int `json:"impression_count"`
+}
+
+// V2NonPublicMetrics and V2OrganicMetrics are only returned for tweets of
+// the authenticated user.
+type V2NonPublicMetrics struct {
+	ImpressionCount   int `json:"impression_count"`
+	URLLinkClicks     int `json:"url_link_clicks"`
+	UserProfileClicks int `json:"user_profile_clicks"`
+	Engagements       int `json:"engagements"`
+}
+
+type V2OrganicMetrics struct {
+	ImpressionCount   int `json:"impression_count"`
+	LikeCount         int `json:"like_count"`
+	ReplyCount        int `json:"reply_count"`
+	RetweetCount      int `json:"retweet_count"`
+	URLLinkClicks     int `json:"url_link_clicks"`
+	UserProfileClicks int `json:"user_profile_clicks"`
+}
+
+type V2Withheld struct {
+	Copyright    bool     `json:"copyright,omitempty"`
+	CountryCodes []string `json:"country_codes,omitempty"`
+}
+
+type V2Attachments struct {
+	PollIDs   []string `json:"poll_ids,omitempty"`
+	MediaKeys []string `json:"media_keys,omitempty"`
+}
+
+type V2Poll struct {
+	ID              string         `json:"id"`
+	Options         []V2PollOption `json:"options"`
+	DurationMinutes int            `json:"duration_minutes"`
+	EndDatetime     string         `json:"end_datetime"`
+	VotingStatus    string         `json:"voting_status"`
+}
+
+type V2PollOption struct {
+	Position int    `json:"position"`
+	Label    string `json:"label"`
+	Votes    int    `json:"votes"`
 }
 
 type V2ReferencedTweet struct {
@@ -62,15 +133,52 @@ type V2ReferencedTweet struct {
 }
 
 type V2User struct {
-	ID              string `json:"id"`
-	Name            string `json:"name"`
-	Username        string `json:"username"`
-	ProfileImageURL string `json:"profile_image_url"`
+	ID              string         `json:"id"`
+	Name            string         `json:"name"`
+	Username        string         `json:"username"`
+	ProfileImageURL string         `json:"profile_image_url"`
+	Description     string         `json:"description,omitempty"`
+	Location        string         `json:"location,omitempty"`
+	URL             string         `json:"url,omitempty"`
+	CreatedAt       string         `json:"created_at,omitempty"`
+	PinnedTweetID   string         `json:"pinned_tweet_id,omitempty"`
+	Protected       bool           `json:"protected,omitempty"`
+	Verified        bool           `json:"verified,omitempty"`
+	VerifiedType    string         `json:"verified_type,omitempty"`
+	PublicMetrics   *V2UserMetrics `json:"public_metrics,omitempty"`
+}
+
+type V2UserMetrics struct {
+	FollowersCount int `json:"followers_count"`
+	FollowingCount int `json:"following_count"`
+	TweetCount     int `json:"tweet_count"`
+	ListedCount    int `json:"listed_count"`
+	LikeCount      int `json:"like_count"`
 }
 
 type V2Includes struct {
 	Users  []V2User  `json:"users"`
 	Tweets []V2Tweet `json:"tweets"`
+	Polls  []V2Poll  `json:"polls"`
+	Media  []V2Media `json:"media"`
+}
+
+// add appends the includes of another page of results.
+func (in *V2Includes) add(other V2Includes) {
+	in.Users = append(in.Users, other.Users...)
+	in.Tweets = append(in.Tweets, other.Tweets...)
+	in.Polls = append(in.Polls, other.Polls...)
+	in.Media = append(in.Media, other.Media...)
+}
+
+type V2Media struct {
+	MediaKey        string `json:"media_key"`
+	Type            string `json:"type"`
+	URL             string `json:"url,omitempty"`
+	PreviewImageURL string `json:"preview_image_url,omitempty"`
+	AltText         string `json:"alt_text,omitempty"`
+	Width           int    `json:"width,omitempty"`
+	Height          int    `json:"height,omitempty"`
 }
 
 type V2Meta struct {
@@ -84,6 +192,7 @@ type V2TweetsResponse struct {
 	Data     []V2Tweet  `json:"data"`
 	Includes V2Includes `json:"includes"`
 	Meta     V2Meta     `json:"meta"`
+	Errors   []V2Error  `json:"errors,omitempty"`
 }
 
 type V2TweetResponse struct {
@@ -93,6 +202,11 @@ type V2TweetResponse struct {
 	} `json:"data"`
 }
 
+type V2TweetLookupResponse struct {
+	Data     V2Tweet    `json:"data"`
+	Includes V2Includes `json:"includes"`
+}
+
 type V2MeResponse struct {
 	Data V2User `json:"data"`
 }
@@ -101,11 +215,40 @@ type V2UserResponse struct {
 	Data V2User `json:"data"`
 }
 
+type V2UsersResponse struct {
+	Data     []V2User   `json:"data"`
+	Includes V2Includes `json:"includes"`
+	Meta     V2Meta     `json:"meta"`
+	Errors   []V2Error  `json:"errors"`
+}
+
+type V2Error struct {
+	Value      string `json:"value"`
+	Detail     string `json:"detail"`
+	Title      string `json:"title"`
+	ResourceID string `json:"resource_id,omitempty"`
+	// Parameter is the request parameter the error is about, such as
+	// "ids", or an expansion like "referenced_tweets.id".
+	Parameter string `json:"parameter,omitempty"`
+}
+
+type V2List struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Description   string `json:"description,omitempty"`
+	Private       bool   `json:"private,omitempty"`
+	OwnerID       string `json:"owner_id,omitempty"`
+	MemberCount   int    `json:"member_count,omitempty"`
+	FollowerCount int    `json:"follower_count,omitempty"`
+}
+
 type V2ListsResponse struct {
-	Data []struct {
-		ID   string `json:"id"`
-		Name string `json:"name"`
-	} `json:"data"`
+	Data []V2List `json:"data"`
+	Meta V2Meta   `json:"meta"`
+}
+
+type V2ListResponse struct {
+	Data V2List `json:"data"`
 }
 
 type OAuth2Token struct {
@@ -118,6 +261,16 @@ type Config struct {
 	ClientID     string      `json:"client_id"`
 	ClientSecret string      `json:"client_secret"`
 	Token        OAuth2Token `json:"token"`
+	// UserID is the ID of the authorized user, kept to save a lookup on
+	// every run.
+	UserID string `json:"user_id,omitempty"`
+
+	// Templates are named -format templates.
+	Templates map[string]string `json:"templates,omitempty"`
+
+	TimeZone     string `json:"time_zone,omitempty"`
+	TimeFormat   string `json:"time_format,omitempty"`
+	AbsoluteTime bool   `json:"absolute_time,omitempty"`
 }
 
 type files []string
@@ -428,6 +581,7 @@ func (app *App) authorization() {
 		if err := app.authorize(); err != nil {
 			log.Fatalf("cannot authorize: %v", err)
 		}
+		app.config.UserID = ""
 		if err := app.saveConfig(); err != nil {
 			log.Fatalf("cannot save configuration: %v", err)
 		}
@@ -507,6 +661,70 @@ func (app *App) callPost(uri string, body any, res any) error {
 	return json.NewDecoder(resp.Body).Decode(&res)
 }
 
+func (app *App) callPut(uri string, body any, res any) error {
+	if err := app.ensureValidToken(); err != nil {
+		return err
+	}
+
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, uri, bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+app.config.Token.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, string(body))
+	}
+	if res == nil {
+		return nil
+	}
+	if app.debug {
+		return json.NewDecoder(io.TeeReader(resp.Body, os.Stdout)).Decode(&res)
+	}
+	return json.NewDecoder(resp.Body).Decode(&res)
+}
+
+func (app *App) callDelete(uri string, res any) error {
+	if err := app.ensureValidToken(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, uri, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+app.config.Token.AccessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, string(body))
+	}
+	if res == nil {
+		return nil
+	}
+	if app.debug {
+		return json.NewDecoder(io.TeeReader(resp.Body, os.Stdout)).Decode(&res)
+	}
+	return json.NewDecoder(resp.Body).Decode(&res)
+}
+
 func (app *App) callPostForm(uri string, param url.Values, res any) error {
 	if err := app.ensureValidToken(); err != nil {
 		return err
@@ -576,6 +794,12 @@ func contentTypeOf(file string) (string, error) {
 }
 
 func (app *App) upload(file string) (string, error) {
+	return app.uploadMedia(file, false)
+}
+
+// uploadMedia uploads file, tagging it with a DM media category when it is
+// going to be attached to a direct message.
+func (app *App) uploadMedia(file string, dm bool) (string, error) {
 	mediaType, _ := contentTypeOf(file)
 	if mediaType == "" {
 		ext := filepath.Ext(strings.ToLower(file))
@@ -604,11 +828,22 @@ func (app *App) upload(file string) (string, error) {
 	initRes := struct {
 		MediaIDString string `json:"media_id_string"`
 	}{}
-	err = app.callPostForm(uri, url.Values{
+	initParams := url.Values{
 		"command":     {"INIT"},
 		"total_bytes": {fmt.Sprint(size)},
 		"media_type":  {mediaType},
-	}, &initRes)
+	}
+	if dm {
+		switch {
+		case mediaType == "image/gif":
+			initParams.Set("media_category", "dm_gif")
+		case strings.HasPrefix(mediaType, "video/"):
+			initParams.Set("media_category", "dm_video")
+		default:
+			initParams.Set("media_category", "dm_image")
+		}
+	}
+	err = app.callPostForm(uri, initParams, &initRes)
 	if err != nil {
 		return "", fmt.Errorf("media upload INIT failed: %v", err)
 	}
@@ -702,6 +937,11 @@ func showV2Tweets(res V2TweetsResponse, asjson bool, verbose bool) {
 	for _, t := range res.Includes.Tweets {
 		tweetMap[t.ID] = t
 	}
+	pollMap := make(map[string]V2Poll)
+	for _, p := range res.Includes.Polls {
+		pollMap[p.ID] = p
+	}
+	width := terminalColumns()
 
 	if asjson {
 		for _, tweet := range res.Data {
@@ -712,52 +952,256 @@ func showV2Tweets(res V2TweetsResponse, asjson bool, verbose bool) {
 		for i := len(res.Data) - 1; i >= 0; i-- {
 			tweet := res.Data[i]
 			user := userMap[tweet.AuthorID]
-			text := tweetText(tweet, tweetMap)
+			text := tweetText(tweet, tweetMap, userMap)
 			color.Set(color.FgHiRed)
 			fmt.Println(user.Username + ": " + user.Name)
 			color.Set(color.Reset)
-			fmt.Println("  " + html.UnescapeString(text))
+			text = renderText(html.UnescapeString(text), tweetEntities(tweet, tweetMap))
+			fmt.Println("  " + wrapText(text, width, 2, "  "))
+			if tweet.Attachments != nil {
+				for _, id := range tweet.Attachments.PollIDs {
+					if poll, ok := pollMap[id]; ok {
+						fmt.Println("  " + strings.ReplaceAll(formatPoll(poll), "\n", "\n  "))
+					}
+				}
+			}
+			for _, line := range formatMetrics(tweet) {
+				fmt.Println("  " + line)
+			}
 			fmt.Println("  " + tweet.ID)
-			fmt.Println("  " + tweet.CreatedAt)
+			fmt.Println("  " + displayTime.full(tweet.CreatedAt))
+			if len(tweet.EditHistory) > 1 {
+				fmt.Println("  edited: " + strings.Join(tweet.EditHistory, " -> "))
+			}
 			fmt.Println()
 		}
 	} else {
+		now := time.Now()
 		for i := len(res.Data) - 1; i >= 0; i-- {
 			tweet := res.Data[i]
 			user := userMap[tweet.AuthorID]
-			text := tweetText(tweet, tweetMap)
+			text := tweetText(tweet, tweetMap, userMap)
+			when := ""
+			if tweet.CreatedAt != "" {
+				when = " (" + displayTime.compact(tweet.CreatedAt, now) + ")"
+			}
+			text = renderText(html.UnescapeString(text), tweetEntities(tweet, tweetMap))
+			if width > 0 {
+				text = wrapText(text, width, stringWidth(user.Username+when+": "), "  ")
+			}
+			color.Set(color.FgHiRed)
+			fmt.Print(user.Username)
+			color.Set(color.Reset)
+			fmt.Print(when + ": ")
+			fmt.Println(text)
+		}
+	}
+}
+
+func showV2Users(users []V2User, format string, asjson bool, verbose bool) {
+	if format == "csv" {
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"id", "username", "name"})
+		for _, user := range users {
+			w.Write([]string{user.ID, user.Username, user.Name})
+		}
+		w.Flush()
+	} else if asjson || format == "json" {
+		for _, user := range users {
+			json.NewEncoder(os.Stdout).Encode(user)
+			os.Stdout.Sync()
+		}
+	} else if verbose {
+		for _, user := range users {
+			color.Set(color.FgHiRed)
+			fmt.Println(user.Username + ": " + user.Name)
+			color.Set(color.Reset)
+			fmt.Println("  " + user.ID)
+			fmt.Println()
+		}
+	} else {
+		for _, user := range users {
 			color.Set(color.FgHiRed)
 			fmt.Print(user.Username)
 			color.Set(color.Reset)
-			fmt.Print(": ")
-			fmt.Println(html.UnescapeString(text))
+			fmt.Println(": " + user.Name)
+		}
+	}
+}
+
+func showUserProfiles(res V2UsersResponse, asjson bool) {
+	if asjson {
+		for _, user := range res.Data {
+			json.NewEncoder(os.Stdout).Encode(user)
+			os.Stdout.Sync()
+		}
+	} else {
+		tweetMap := make(map[string]V2Tweet)
+		for _, t := range res.Includes.Tweets {
+			tweetMap[t.ID] = t
+		}
+		for _, user := range res.Data {
+			color.Set(color.FgHiRed)
+			fmt.Println(user.Username + ": " + user.Name)
+			color.Set(color.Reset)
+			fmt.Println("  " + user.ID)
+			if card := formatUserCard(user, tweetMap); card != "" {
+				fmt.Println("  " + strings.ReplaceAll(card, "\n", "\n  "))
+			}
+			fmt.Println()
 		}
 	}
+	for _, e := range res.Errors {
+		log.Printf("%s: %s", e.Value, e.Detail)
+	}
 }
 
-func tweetText(tweet V2Tweet, tweetMap map[string]V2Tweet) string {
+// parseUserList reads usernames or user IDs, one per line. It accepts the
+// output of showV2Users in any format, so exported lists can be imported again.
+func parseUserList(r io.Reader) ([]string, error) {
+	var users []string
+	inCSV := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// verbose output puts details on indented lines below the user
+		if strings.HasPrefix(scanner.Text(), " ") {
+			continue
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			var user V2User
+			if err := json.Unmarshal([]byte(line), &user); err != nil {
+				return nil, err
+			}
+			if user.Username != "" {
+				users = append(users, "@"+user.Username)
+			} else if user.ID != "" {
+				users = append(users, "id:"+user.ID)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "id,username") {
+			// -o csv writes "id,username,name" rows under this header
+			inCSV = true
+			continue
+		}
+		if inCSV || isCSVUserRow(line) {
+			record, err := csv.NewReader(strings.NewReader(line)).Read()
+			if err != nil {
+				return nil, err
+			}
+			if len(record) >= 2 {
+				users = append(users, "@"+record[1])
+			}
+			continue
+		}
+		prefix := ""
+		if strings.HasPrefix(line, "id:") {
+			prefix, line = "id:", line[len("id:"):]
+		}
+		if i := strings.IndexAny(line, ": \t"); i >= 0 {
+			line = line[:i]
+		}
+		if id, username := parseUserRef(prefix + line); id != "" {
+			users = append(users, "id:"+id)
+		} else {
+			users = append(users, "@"+username)
+		}
+	}
+	return users, scanner.Err()
+}
+
+// isCSVUserRow reports whether line looks like a CSV row of -o csv, which
+// starts with the numeric user ID. Plain output lines may contain commas in
+// the display name, but start with the username.
+func isCSVUserRow(line string) bool {
+	id, _, ok := strings.Cut(line, ",")
+	if !ok {
+		return false
+	}
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err == nil
+}
+
+func formatPoll(poll V2Poll) string {
+	total := 0
+	for _, o := range poll.Options {
+		total += o.Votes
+	}
+	var lines []string
+	for _, o := range poll.Options {
+		percent := 0
+		if total > 0 {
+			percent = o.Votes * 100 / total
+		}
+		lines = append(lines, fmt.Sprintf("[%d] %s: %d (%d%%)", o.Position, o.Label, o.Votes, percent))
+	}
+	status := fmt.Sprintf("%d votes", total)
+	if poll.VotingStatus != "" {
+		status += ", " + poll.VotingStatus
+	}
+	if poll.EndDatetime != "" {
+		status += ", ends " + poll.EndDatetime
+	}
+	return strings.Join(append(lines, status), "\n")
+}
+
+// longText returns the text of tweet and its entities. For long posts they
+// are taken from note_tweet, since text is truncated.
+func longText(tweet V2Tweet) (string, *V2Entities) {
+	if tweet.NoteTweet != nil && tweet.NoteTweet.Text != "" {
+		return tweet.NoteTweet.Text, tweet.NoteTweet.Entities
+	}
+	return tweet.Text, tweet.Entities
+}
+
+// fullText returns the untruncated text of tweet with its links expanded.
+func fullText(tweet V2Tweet) string {
+	return expandURLs(longText(tweet))
+}
+
+func tweetText(tweet V2Tweet, tweetMap map[string]V2Tweet, userMap map[string]V2User) string {
 	for _, ref := range tweet.ReferencedTweets {
 		if ref.Type == "retweeted" {
 			if rt, ok := tweetMap[ref.ID]; ok {
-				return "RT: " + rt.Text
+				if u, ok := userMap[rt.AuthorID]; ok && u.Username != "" {
+					return "RT @" + u.Username + ": " + fullText(rt)
+				}
+				return "RT: " + fullText(rt)
 			}
 		}
 	}
 	for _, ref := range tweet.ReferencedTweets {
 		if ref.Type == "quoted" {
 			if qt, ok := tweetMap[ref.ID]; ok {
-				return tweet.Text + "\n  > " + qt.Text
+				if u, ok := userMap[qt.AuthorID]; ok && u.Username != "" {
+					return fullText(tweet) + "\n  > @" + u.Username + ": " + fullText(qt)
+				}
+				return fullText(tweet) + "\n  > " + fullText(qt)
 			}
 		}
 	}
-	return tweet.Text
+	return fullText(tweet)
 }
 
 func v2TweetFields() map[string]string {
 	return map[string]string{
-		"tweet.fields": "created_at,author_id,text,referenced_tweets",
+		"tweet.fields": "created_at,author_id,text,referenced_tweets,attachments,edit_history_tweet_ids,public_metrics,entities,note_tweet",
 		"user.fields":  "name,username,profile_image_url",
-		"expansions":   "author_id,referenced_tweets.id",
+		"poll.fields":  "duration_minutes,end_datetime,options,voting_status",
+		"media.fields": "type,url,preview_image_url,alt_text,width,height",
+		"expansions":   "author_id,referenced_tweets.id,referenced_tweets.id.author_id,attachments.poll_ids,attachments.media_keys",
+	}
+}
+
+func v2UserFields() map[string]string {
+	return map[string]string{
+		"user.fields":  "name,username,profile_image_url,description,location,url,created_at,pinned_tweet_id,protected,verified,verified_type,public_metrics",
+		"tweet.fields": "created_at,text,entities,note_tweet",
+		"expansions":   "pinned_tweet_id",
 	}
 }
 
@@ -765,12 +1209,22 @@ func (app *App) getMyID() (string, error) {
 	if app.myID != "" {
 		return app.myID, nil
 	}
+	if app.config.UserID != "" {
+		app.myID = app.config.UserID
+		return app.myID, nil
+	}
 	var res V2MeResponse
 	err := app.callGet("https://api.twitter.com/2/users/me", nil, &res)
 	if err != nil {
 		return "", err
 	}
 	app.myID = res.Data.ID
+	if app.configFile != "" {
+		app.config.UserID = app.myID
+		if err := app.saveConfig(); err != nil {
+			log.Printf("cannot save configuration: %v", err)
+		}
+	}
 	return app.myID, nil
 }
 
@@ -780,12 +1234,12 @@ func (app *App) searchTweets() {
 		sinceID = strconv.FormatInt(app.sinceID, 10)
 	}
 	for {
-		res, err := app.fetchSearchTweets(app.search, app.count, app.since, app.until, sinceID)
+		res, err := app.fetchSearchTweets(app.search, app.count, app.since, app.until, sinceID, app.archive)
 		if err != nil {
 			log.Fatalf("cannot search tweets: %v", err)
 		}
 		if len(res.Data) > 0 {
-			showV2Tweets(res, app.asjson, app.verbose)
+			app.showTweets(res)
 		}
 		if app.delay == 0 {
 			break
@@ -802,7 +1256,7 @@ func (app *App) showReplies() {
 	if err != nil {
 		log.Fatalf("cannot get mentions: %v", err)
 	}
-	showV2Tweets(res, app.asjson, app.verbose)
+	app.showTweets(res)
 }
 
 func (app *App) showListTweets() {
@@ -810,7 +1264,15 @@ func (app *App) showListTweets() {
 	if err != nil {
 		log.Fatalf("cannot get list tweets: %v", err)
 	}
-	showV2Tweets(res, app.asjson, app.verbose)
+	app.showTweets(res)
+}
+
+func (app *App) showLikedTweets() {
+	res, err := app.fetchLikedTweets(app.user, app.count)
+	if err != nil {
+		log.Fatalf("cannot get liked tweets: %v", err)
+	}
+	app.showTweets(res)
 }
 
 func (app *App) showUserTweets() {
@@ -825,7 +1287,7 @@ func (app *App) showUserTweets() {
 	if err != nil {
 		log.Fatalf("cannot get tweets: %v", err)
 	}
-	showV2Tweets(res, app.asjson, app.verbose)
+	app.showTweets(res)
 }
 
 func (app *App) favoriteTweet() {
@@ -838,16 +1300,134 @@ func (app *App) favoriteTweet() {
 	fmt.Println("favorited")
 }
 
+func (app *App) doMute() {
+	if err := app.muteUser(app.mute); err != nil {
+		log.Fatalf("cannot mute user: %v", err)
+	}
+	fmt.Println("muted:", app.mute)
+}
+
+func (app *App) doUnmute() {
+	if err := app.unmuteUser(app.unmute); err != nil {
+		log.Fatalf("cannot unmute user: %v", err)
+	}
+	fmt.Println("unmuted:", app.unmute)
+}
+
+func (app *App) doBlock() {
+	if err := app.blockUser(app.block); err != nil {
+		log.Fatalf("cannot block user: %v", err)
+	}
+	fmt.Println("blocked:", app.block)
+}
+
+func (app *App) doUnblock() {
+	if err := app.unblockUser(app.unblock); err != nil {
+		log.Fatalf("cannot unblock user: %v", err)
+	}
+	fmt.Println("unblocked:", app.unblock)
+}
+
+func (app *App) showMuted() {
+	users, err := app.fetchMuting()
+	if err != nil {
+		log.Fatalf("cannot get muted users: %v", err)
+	}
+	showV2Users(users, app.output, app.asjson, app.verbose)
+}
+
+func (app *App) showBlocked() {
+	users, err := app.fetchBlocking()
+	if err != nil {
+		log.Fatalf("cannot get blocked users: %v", err)
+	}
+	showV2Users(users, app.output, app.asjson, app.verbose)
+}
+
+func (app *App) showEngagingUsers(tweet string, fetch func(string, string) ([]V2User, error)) {
+	tweetID, err := parseTweetID(tweet)
+	if err != nil {
+		log.Fatal(err)
+	}
+	users, err := fetch(tweetID, app.count)
+	if err != nil {
+		log.Fatalf("cannot get users: %v", err)
+	}
+	showV2Users(users, app.output, app.asjson, app.verbose)
+}
+
+func (app *App) showQuoteTweets() {
+	tweetID, err := parseTweetID(app.quotes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	res, err := app.fetchQuoteTweets(tweetID, app.count)
+	if err != nil {
+		log.Fatalf("cannot get quote tweets: %v", err)
+	}
+	app.showTweets(res)
+}
+
+func (app *App) showWhois() {
+	var users []string
+	for _, user := range append(strings.Split(app.whois, ","), flag.Args()...) {
+		if user = strings.TrimSpace(user); user != "" {
+			users = append(users, user)
+		}
+	}
+	res, err := app.fetchUserProfiles(users)
+	if err != nil {
+		log.Fatalf("cannot get users: %v", err)
+	}
+	showUserProfiles(res, app.asjson)
+}
+
+// importUsers calls fn for every user in file. verb and done name the action
+// in messages, such as "block" and "blocked".
+func (app *App) importUsers(file, verb, done string, fn func(string) error) {
+	b, err := readFile(file)
+	if err != nil {
+		log.Fatalf("cannot read user list: %v", err)
+	}
+	users, err := parseUserList(bytes.NewReader(b))
+	if err != nil {
+		log.Fatalf("cannot parse user list: %v", err)
+	}
+	failed := 0
+	for _, user := range users {
+		if err := fn(user); err != nil {
+			log.Printf("cannot %s %s: %v", verb, user, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: %s\n", done, user)
+	}
+	if failed > 0 {
+		log.Fatalf("%d of %d users failed", failed, len(users))
+	}
+}
+
 func (app *App) fromFile() {
 	text, err := readFile(app.fromfile)
 	if err != nil {
 		log.Fatalf("cannot read a new tweet: %v", err)
 	}
-	id, err := app.createTweet(strings.TrimRight(string(text), "\r\n"), app.inreply, app.media)
+	id, err := app.createTweet(strings.TrimRight(string(text), "\r\n"), app.tweetOptions())
 	if err != nil {
 		log.Fatalf("cannot post tweet: %v", err)
 	}
-	fmt.Println("tweeted:", id)
+	app.printTweeted(id)
+}
+
+func (app *App) doHideReply(tweetID string, hidden bool) {
+	if err := app.hideReply(tweetID, hidden); err != nil {
+		log.Fatalf("cannot change reply visibility: %v", err)
+	}
+	if hidden {
+		fmt.Println("hidden:", tweetID)
+	} else {
+		fmt.Println("unhidden:", tweetID)
+	}
 }
 
 func (app *App) doRetweet() {
@@ -867,7 +1447,7 @@ func (app *App) doStream() {
 		if err != nil {
 			log.Printf("cannot get tweets: %v", err)
 		} else if len(res.Data) > 0 {
-			showV2Tweets(res, app.asjson, app.verbose)
+			app.showTweets(res)
 			sinceID = res.Meta.NewestID
 		}
 		time.Sleep(app.delay)
@@ -886,30 +1466,214 @@ func (app *App) doShow() {
 	if err != nil {
 		log.Fatalf("cannot get tweets: %v", err)
 	}
-	showV2Tweets(res, app.asjson, app.verbose)
+	app.showTweets(res)
+}
+
+func (app *App) printTweeted(id string) {
+	if app.edit != "" {
+		fmt.Println("edited:", id)
+	} else {
+		fmt.Println("tweeted:", id)
+	}
+}
+
+func (app *App) tweetOptions() tweetOptions {
+	return tweetOptions{
+		InReplyTo:     app.inreply,
+		QuoteTweetID:  app.quote,
+		MediaIDs:      app.media,
+		PollOptions:   parsePollOptions(app.poll),
+		PollDuration:  app.pollDuration,
+		ReplySettings: app.replySettings,
+		EditTweetID:   app.edit,
+	}
+}
+
+// command returns the function running the command selected by the flags,
+// and the -o formats it can write, which are none for most commands.
+func (app *App) command() (func(), []string) {
+	tweets := append([]string{"json"}, tweetOutputFormats...)
+	users := []string{"csv", "json"}
+	switch {
+	case app.queueEdit != "":
+		return app.editQueued, nil
+	case app.at != "":
+		return app.schedulePost, nil
+	case app.queueCancel != "":
+		return app.cancelQueued, nil
+	case app.queue:
+		return app.showQueue, nil
+	case app.scheduler:
+		return app.runScheduler, nil
+	case app.counts:
+		return app.showTweetCounts, users
+	case len(app.search) > 0:
+		return app.searchTweets, tweets
+	case app.reply:
+		return app.showReplies, tweets
+	case app.spaces != "" || app.space != "" || app.spacesBy != "":
+		return app.showSpaces, nil
+	case app.isListCommand():
+		if app.listMembers || app.listFollowers {
+			return app.listCommand, users
+		}
+		return app.listCommand, nil
+	case app.list != "":
+		return app.showListTweets, tweets
+	case app.likes:
+		return app.showLikedTweets, tweets
+	case app.user != "":
+		return app.showUserTweets, tweets
+	case app.favorite != "":
+		return app.favoriteTweet, nil
+	case app.hide != "":
+		return func() { app.doHideReply(app.hide, true) }, nil
+	case app.unhide != "":
+		return func() { app.doHideReply(app.unhide, false) }, nil
+	case app.mute != "":
+		return app.doMute, nil
+	case app.unmute != "":
+		return app.doUnmute, nil
+	case app.block != "":
+		return app.doBlock, nil
+	case app.unblock != "":
+		return app.doUnblock, nil
+	case app.muted:
+		return app.showMuted, users
+	case app.blocked:
+		return app.showBlocked, users
+	case app.importMuted != "":
+		return func() { app.importUsers(app.importMuted, "mute", "muted", app.muteUser) }, nil
+	case app.importBlocked != "":
+		return func() { app.importUsers(app.importBlocked, "block", "blocked", app.blockUser) }, nil
+	case app.whois != "":
+		return app.showWhois, nil
+	case app.likingUsers != "":
+		return func() { app.showEngagingUsers(app.likingUsers, app.fetchLikingUsers) }, users
+	case app.retweetedBy != "":
+		return func() { app.showEngagingUsers(app.retweetedBy, app.fetchRetweetedBy) }, users
+	case app.quotes != "":
+		return app.showQuoteTweets, tweets
+	case app.lookup:
+		return app.showLookup, tweets
+	case app.thread != "":
+		return app.showThread, nil
+	case app.dmTo != "":
+		return app.sendDM, nil
+	case app.dmWith != "":
+		return app.showDMConversation, nil
+	case app.dm:
+		return app.showDMs, nil
+	case app.fromfile != "":
+		return app.fromFile, nil
+	case app.threadFile != "":
+		return app.postThread, nil
+	case flag.NArg() == 0 && len(app.media) == 0 && app.quote == "" && app.poll == "" && app.edit == "":
+		switch {
+		case app.inreply != "":
+			return app.doRetweet, nil
+		case app.delay > 0:
+			return app.doStream, tweets
+		}
+		return app.doShow, tweets
+	}
+	return app.doTweet, nil
+}
+
+// isQueueCommand reports whether the command only works on the local queue
+// of scheduled posts, without the API.
+func (app *App) isQueueCommand() bool {
+	return app.queueEdit != "" || app.at != "" || app.queueCancel != "" || app.queue
 }
 
 func (app *App) doTweet() {
 	text := strings.Join(flag.Args(), " ")
-	id, err := app.createTweet(text, app.inreply, app.media)
+	id, err := app.createTweet(text, app.tweetOptions())
 	if err != nil {
 		log.Fatalf("cannot post tweet: %v", err)
 	}
-	fmt.Println("tweeted:", id)
+	app.printTweeted(id)
 }
 
 type App struct {
 	profile  string
 	reply    bool
+	likes    bool
 	list     string
 	asjson   bool
 	user     string
 	favorite string
 	search   string
 	inreply  string
+	quote    string
 	delay    time.Duration
 	media    files
 
+	poll          string
+	pollDuration  time.Duration
+	replySettings string
+	edit          string
+	hide          string
+	unhide        string
+
+	mute          string
+	unmute        string
+	block         string
+	unblock       string
+	muted         bool
+	blocked       bool
+	importMuted   string
+	importBlocked string
+	whois         string
+	likingUsers   string
+	retweetedBy   string
+	quotes        string
+	lookup        bool
+	threadFile    string
+	dm            bool
+	dmWith        string
+	dmTo          string
+	thread        string
+	spaces        string
+	spaceState    string
+	space         string
+	spacesBy      string
+
+	lists           bool
+	listCreate      string
+	listUpdate      string
+	listDelete      string
+	listName        string
+	listDescription string
+	listPrivate     bool
+	listAdd         string
+	listRemove      string
+	listFollow      string
+	listUnfollow    string
+	listPin         string
+	listUnpin       string
+	listMembers     bool
+	listFollowers   bool
+
+	counts       bool
+	granularity  string
+	archive      bool
+	output       string
+	format       string
+	tmpl         *template.Template
+	wroteHeader  bool
+	timeZone     string
+	timeFormat   string
+	absoluteTime bool
+	sort         string
+
+	at          string
+	scheduler   bool
+	missed      string
+	queue       bool
+	queueEdit   string
+	queueCancel string
+
 	fromfile string
 	count    string
 	since    string
@@ -954,7 +1718,7 @@ func isTimeFormat(t string) bool {
 func (app *App) uploadMedias() {
 	var err error
 	for i := range app.media {
-		app.media[i], err = app.upload(app.media[i])
+		app.media[i], err = app.uploadMedia(app.media[i], app.dmTo != "")
 		if err != nil {
 			log.Fatalf("cannot upload media: %v", err)
 		}
@@ -967,17 +1731,78 @@ func parseFlags(app *App) {
 	flag.StringVar(&app.list, "l", "", "show tweets")
 	flag.BoolVar(&app.asjson, "json", false, "show tweets as json")
 	flag.StringVar(&app.user, "u", "", "show user timeline")
+	flag.BoolVar(&app.likes, "likes", false, "show liked tweets")
 	flag.StringVar(&app.favorite, "f", "", "specify favorite ID")
 	flag.StringVar(&app.search, "s", "", "search word")
+	flag.BoolVar(&app.counts, "counts", false, "show tweet counts for the search word")
+	flag.StringVar(&app.granularity, "granularity", "hour", "granularity of tweet counts (minute, hour or day)")
+	flag.BoolVar(&app.archive, "archive", false, "use full-archive endpoints")
+	flag.StringVar(&app.output, "o", "", "output format")
+	flag.StringVar(&app.format, "format", "", "format tweets with a template")
+	flag.StringVar(&app.timeZone, "tz", "", "time zone of shown times")
+	flag.StringVar(&app.sort, "sort", "", "sort tweets by a metric")
+	flag.StringVar(&app.timeFormat, "time-format", "", "strftime format of shown times")
+	flag.BoolVar(&app.absoluteTime, "absolute-time", false, "show full times instead of relative times")
 	flag.StringVar(&app.inreply, "i", "", "specify in-reply ID, if not specify text, it will be RT.")
+	flag.StringVar(&app.quote, "q", "", "specify quote tweet ID or URL")
 	flag.Var(&app.media, "m", "upload media")
+	flag.StringVar(&app.poll, "poll", "", "post a poll with options separated by \"|\"")
+	flag.DurationVar(&app.pollDuration, "poll-duration", 24*time.Hour, "poll duration")
+	flag.StringVar(&app.replySettings, "reply-settings", "", "who can reply: following, mentionedUsers or subscribers")
+	flag.StringVar(&app.edit, "edit", "", "edit a tweet")
+	flag.StringVar(&app.hide, "hide", "", "hide a reply to your tweet")
+	flag.StringVar(&app.unhide, "unhide", "", "unhide a reply to your tweet")
 	flag.DurationVar(&app.delay, "S", 0, "delay")
 	flag.BoolVar(&app.verbose, "v", false, "detail display")
 	flag.BoolVar(&app.debug, "debug", false, "debug json")
 	flag.BoolVar(&app.showVersion, "V", false, "Print the version")
 	flag.BoolVar(&app.mcp, "mcp", false, "run as MCP server")
 
+	flag.StringVar(&app.mute, "mute", "", "mute user")
+	flag.StringVar(&app.unmute, "unmute", "", "unmute user")
+	flag.StringVar(&app.block, "block", "", "block user")
+	flag.StringVar(&app.unblock, "unblock", "", "unblock user")
+	flag.BoolVar(&app.muted, "muted", false, "show muted users")
+	flag.BoolVar(&app.blocked, "blocked", false, "show blocked users")
+	flag.StringVar(&app.importMuted, "import-muted", "", "mute users listed in a file(\"-\" means STDIN)")
+	flag.StringVar(&app.importBlocked, "import-blocked", "", "block users listed in a file(\"-\" means STDIN)")
+	flag.StringVar(&app.whois, "whois", "", "show user profiles")
+	flag.StringVar(&app.likingUsers, "liking-users", "", "show users who liked a tweet")
+	flag.StringVar(&app.retweetedBy, "retweeted-by", "", "show users who retweeted a tweet")
+	flag.StringVar(&app.quotes, "quotes", "", "show quote tweets of a tweet")
+	flag.BoolVar(&app.lookup, "lookup", false, "show tweets by IDs or URLs")
+	flag.StringVar(&app.thread, "thread", "", "show tweet with its conversation thread")
+
 	flag.StringVar(&app.fromfile, "ff", "", "post utf-8 string from a file(\"-\" means STDIN)")
+	flag.BoolVar(&app.dm, "dm", false, "show direct messages")
+	flag.StringVar(&app.dmWith, "dm-with", "", "show direct message conversation with user")
+	flag.StringVar(&app.dmTo, "dm-to", "", "send direct message to user")
+	flag.StringVar(&app.spaces, "spaces", "", "search spaces")
+	flag.StringVar(&app.spaceState, "space-state", "all", "state of spaces to search: live, scheduled or all")
+	flag.StringVar(&app.space, "space", "", "show a space")
+	flag.StringVar(&app.spacesBy, "spaces-by", "", "show spaces created by user")
+	flag.BoolVar(&app.lists, "lists", false, "show your lists")
+	flag.StringVar(&app.listCreate, "list-create", "", "create a list")
+	flag.StringVar(&app.listUpdate, "list-update", "", "update a list")
+	flag.StringVar(&app.listDelete, "list-delete", "", "delete a list")
+	flag.StringVar(&app.listName, "list-name", "", "new name of the list")
+	flag.StringVar(&app.listDescription, "list-description", "", "description of the list")
+	flag.BoolVar(&app.listPrivate, "list-private", false, "make the list private")
+	flag.StringVar(&app.listAdd, "list-add", "", "add user to the list specified with -l")
+	flag.StringVar(&app.listRemove, "list-remove", "", "remove user from the list specified with -l")
+	flag.StringVar(&app.listFollow, "list-follow", "", "follow a list")
+	flag.StringVar(&app.listUnfollow, "list-unfollow", "", "unfollow a list")
+	flag.StringVar(&app.listPin, "list-pin", "", "pin a list")
+	flag.StringVar(&app.listUnpin, "list-unpin", "", "unpin a list")
+	flag.BoolVar(&app.listMembers, "list-members", false, "show members of the list specified with -l")
+	flag.BoolVar(&app.listFollowers, "list-followers", false, "show followers of the list specified with -l")
+	flag.StringVar(&app.threadFile, "ft", "", "post a thread from a file(\"-\" means STDIN)")
+	flag.StringVar(&app.at, "at", "", "schedule the post for the time")
+	flag.BoolVar(&app.scheduler, "scheduler", false, "post scheduled posts when they are due")
+	flag.StringVar(&app.missed, "missed", "post", "what to do with missed scheduled posts: post or skip")
+	flag.BoolVar(&app.queue, "queue", false, "show scheduled posts")
+	flag.StringVar(&app.queueEdit, "queue-edit", "", "edit a scheduled post")
+	flag.StringVar(&app.queueCancel, "queue-cancel", "", "cancel a scheduled post")
 	flag.StringVar(&app.count, "count", "", "fetch tweets count")
 	flag.StringVar(&app.since, "since", "", "fetch tweets since date.")
 	flag.StringVar(&app.until, "until", "", "fetch tweets until date.")
@@ -990,19 +1815,87 @@ func parseFlags(app *App) {
 	flag.Parse()
 }
 
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 const usage = `Usage of twty:
   -a PROFILE: switch profile to load configuration file.
   -f ID: specify favorite ID
   -i ID: specify in-reply ID, if not specify text, it will be RT.
   -l LIST: show list's timeline (list ID or user/list-name)
+  -q ID: quote tweet ID (or tweet URL)
   -m FILE: upload media
+  -poll "A|B|C": post a poll with 2 to 4 options.
+  -poll-duration DURATION: poll duration in whole minutes between 5m and 168h (default 24h).
+  -reply-settings WHO: limit replies to following, mentionedUsers or subscribers.
+  -edit ID: replace the tweet ID with the text (and -m media).
+  -hide ID: hide a reply to your tweet.
+  -unhide ID: unhide a reply to your tweet.
   -u USER: show user's timeline
+  -likes: show tweets you liked (or USER liked with -u)
   -s WORD: search timeline
   -S DELAY tweets after DELAY
+  -counts: show tweet counts for the search word as a histogram (with -s)
+  -granularity UNIT: granularity of -counts: minute, hour or day (default hour)
+  -archive: search the full archive with -s, -counts (requires full-archive access)
+  -o FORMAT: output format of tweets: csv, tsv, ndjson, markdown, html or json (-counts and user lists: csv or json)
+             json writes tweets with their authors, referenced tweets and media (schema version 1)
+  -format TEMPLATE: format tweets with a Go template, or a template named in the configuration
+  -tz ZONE: show times in the time zone ZONE, like Asia/Tokyo (default local time)
+  -time-format FORMAT: show full times with a strftime format (see README)
+  -absolute-time: show full times instead of relative times in the default mode
+  -sort METRIC: show top tweets first by likes, retweets, replies, quotes, bookmarks, impressions or engagement
   -json: as JSON
   -r: show replies
   -v: detail display
   -ff FILENAME: post utf-8 string from a file("-" means STDIN)
+  -ft FILENAME: post a thread from a file("-" means STDIN), parts separated by "---" lines
+  -at TIME: schedule the post (text, -ff, -ft, -m, -i, -reply-settings) for TIME: "YYYY-MM-DD HH:MM", "HH:MM", RFC 3339 or "+DURATION"
+  -scheduler: post scheduled posts when they are due (checks every -S, default 30s)
+  -missed POLICY: post or skip scheduled posts missed while the scheduler was not running (default post)
+  -queue: show scheduled posts
+  -queue-edit ID: change the scheduled post ID (with -at, text, -ff, -ft, -m, -i, -reply-settings)
+  -queue-cancel ID: cancel the scheduled post ID
+  -mute USER: mute USER (username, or id:ID for a user ID)
+  -unmute USER: unmute USER
+  -block USER: block USER
+  -unblock USER: unblock USER
+  -muted: show muted users
+  -blocked: show blocked users
+  -import-muted FILENAME: mute users listed in a file("-" means STDIN)
+  -import-blocked FILENAME: block users listed in a file("-" means STDIN)
+  -whois USER: show USER's profile (comma separated or extra arguments for more users)
+  -liking-users ID: show users who liked the tweet ID
+  -retweeted-by ID: show users who retweeted the tweet ID
+  -quotes ID: show quote tweets of the tweet ID
+  -lookup: show tweets by IDs or URLs given as arguments or on STDIN
+  -thread ID: show the tweet ID (or tweet URL) with its conversation thread
+  -dm: show recent direct messages (with -S, notify new ones)
+  -dm-with USER: show direct message conversation with USER
+  -dm-to USER: send the text (and -m media) as a direct message to USER
+  -spaces WORD: search spaces
+  -space-state STATE: state of spaces to search: live, scheduled or all (default all)
+  -space ID: show the space ID
+  -spaces-by USER: show spaces created by USER
+  -lists: show your lists
+  -list-create NAME: create a list (with -list-description, -list-private)
+  -list-update LIST: update a list (with -list-name, -list-description, -list-private)
+  -list-delete LIST: delete a list
+  -list-add USER: add USER to the list specified with -l
+  -list-remove USER: remove USER from the list specified with -l
+  -list-follow LIST: follow a list
+  -list-unfollow LIST: unfollow a list
+  -list-pin LIST: pin a list
+  -list-unpin LIST: unpin a list
+  -list-members: show members of the list specified with -l
+  -list-followers: show followers of the list specified with -l
   -count NUMBER: show NUMBER tweets at timeline.
   -since DATE: show tweets created after the DATE (ex. 2017-05-01)
   -until DATE: show tweets created before the DATE (ex. 2017-05-31)
@@ -1029,37 +1922,81 @@ func main() {
 		if app.config.Token.AccessToken == "" {
 			log.Fatal("no access token configured; run twty without -mcp first to authorize")
 		}
+		app.setupTimeDisplay()
 		app.serveMCP()
 		return
 	}
 
-	app.authorization()
+	if app.quote != "" {
+		id, err := parseTweetID(app.quote)
+		if err != nil {
+			log.Fatal(err)
+		}
+		app.quote = id
+		if len(app.media) > 0 {
+			log.Fatal("cannot attach media to a quote tweet")
+		}
+	}
+	if app.edit != "" {
+		id, err := parseTweetID(app.edit)
+		if err != nil {
+			log.Fatal(err)
+		}
+		app.edit = id
+	}
+	if app.poll != "" {
+		if err := validatePoll(app.tweetOptions()); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if app.replySettings != "" {
+		if err := validateReplySettings(app.replySettings); err != nil {
+			log.Fatal(err)
+		}
+		if app.fromfile == "" && app.threadFile == "" && flag.NArg() == 0 && len(app.media) == 0 && app.quote == "" && app.poll == "" && app.edit == "" && app.queueEdit == "" {
+			log.Fatal("-reply-settings needs a tweet to post")
+		}
+	}
 
-	if len(app.media) > 0 {
-		app.uploadMedias()
+	run, formats := app.command()
+	if app.output != "" {
+		if len(formats) == 0 {
+			log.Fatal("-o is not supported by this command")
+		}
+		if !slices.Contains(formats, app.output) {
+			log.Fatalf("unsupported output format %q: use %s", app.output, strings.Join(formats, ", "))
+		}
+	}
+	if app.isQueueCommand() {
+		run()
+		return
 	}
 
-	if len(app.search) > 0 {
-		app.searchTweets()
-	} else if app.reply {
-		app.showReplies()
-	} else if app.list != "" {
-		app.showListTweets()
-	} else if app.user != "" {
-		app.showUserTweets()
-	} else if app.favorite != "" {
-		app.favoriteTweet()
-	} else if app.fromfile != "" {
-		app.fromFile()
-	} else if flag.NArg() == 0 && len(app.media) == 0 {
-		if app.inreply != "" {
-			app.doRetweet()
-		} else if app.delay > 0 {
-			app.doStream()
-		} else {
-			app.doShow()
+	app.authorization()
+	app.setupTimeDisplay()
+
+	if app.sort != "" {
+		if _, ok := tweetSortKeys[app.sort]; !ok {
+			log.Fatalf("unknown sort key %q: use %s", app.sort, sortKeyNames())
 		}
-	} else {
-		app.doTweet()
+		// Polling shows the new tweets as they come, so there is no whole
+		// list to sort.
+		if app.delay > 0 {
+			log.Fatal("-sort cannot be used with -S")
+		}
+	}
+	if app.format != "" {
+		tmpl, err := parseTweetTemplate(app.format, app.config.Templates)
+		if err != nil {
+			log.Fatalf("cannot parse format: %v", err)
+		}
+		app.tmpl = tmpl
+	}
+
+	// The scheduler uploads the media of each post when it is due, and a
+	// thread checks its media before uploading them.
+	if len(app.media) > 0 && !app.scheduler && app.threadFile == "" {
+		app.uploadMedias()
 	}
+	run()
 }
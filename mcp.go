@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type jsonrpcRequest struct {
@@ -51,8 +52,8 @@ var mcpTools = []mcpTool{
 	},
 	{
 		Name:        "search_tweets",
-		Description: "Search recent tweets on X (Twitter)",
-		InputSchema: json.RawMessage(`{"type":"object","properties":{"query":{"type":"string","description":"Search query"},"count":{"type":"integer","minimum":1,"maximum":100,"description":"Number of tweets to fetch (max 100)"}},"required":["query"]}`),
+		Description: "Search tweets on X (Twitter) from the last seven days, or from the full archive when archive is set",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"query":{"type":"string","description":"Search query"},"count":{"type":"integer","minimum":1,"maximum":100,"description":"Number of tweets to fetch (max 100)"},"archive":{"type":"boolean","description":"Search the full archive instead of the last seven days (requires full-archive access)"}},"required":["query"]}`),
 	},
 	{
 		Name:        "get_mentions",
@@ -64,15 +65,60 @@ var mcpTools = []mcpTool{
 		Description: "Get tweets from a specific user on X (Twitter)",
 		InputSchema: json.RawMessage(`{"type":"object","properties":{"username":{"type":"string","description":"Twitter username (without @)"},"count":{"type":"integer","minimum":1,"maximum":100,"description":"Number of tweets to fetch (max 100)"}},"required":["username"]}`),
 	},
+	{
+		Name:        "get_liked_tweets",
+		Description: "Get tweets liked by you or a specific user on X (Twitter)",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"username":{"type":"string","description":"Twitter username (without @), defaults to you"},"count":{"type":"integer","minimum":1,"maximum":1000,"description":"Number of tweets to fetch"}}}`),
+	},
 	{
 		Name:        "get_list_tweets",
 		Description: "Get tweets from a list on X (Twitter)",
 		InputSchema: json.RawMessage(`{"type":"object","properties":{"list":{"type":"string","description":"List ID or owner/list-name"},"count":{"type":"integer","minimum":1,"maximum":100,"description":"Number of tweets to fetch (max 100)"}},"required":["list"]}`),
 	},
+	{
+		Name:        "get_thread",
+		Description: "Get a tweet and its conversation thread on X (Twitter) as an indented reply tree",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"tweet_id":{"type":"string","description":"ID of any tweet in the conversation"}},"required":["tweet_id"]}`),
+	},
+	{
+		Name:        "get_liking_users",
+		Description: "Get users who liked a tweet on X (Twitter)",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"tweet_id":{"type":"string","description":"Tweet ID or URL"},"count":{"type":"integer","minimum":1,"description":"Maximum number of users to fetch"}},"required":["tweet_id"]}`),
+	},
+	{
+		Name:        "get_retweeted_by",
+		Description: "Get users who retweeted a tweet on X (Twitter)",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"tweet_id":{"type":"string","description":"Tweet ID or URL"},"count":{"type":"integer","minimum":1,"description":"Maximum number of users to fetch"}},"required":["tweet_id"]}`),
+	},
+	{
+		Name:        "get_quote_tweets",
+		Description: "Get quote tweets of a tweet on X (Twitter)",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"tweet_id":{"type":"string","description":"Tweet ID or URL"},"count":{"type":"integer","minimum":1,"description":"Maximum number of tweets to fetch"}},"required":["tweet_id"]}`),
+	},
+	{
+		Name:        "get_user_profile",
+		Description: "Look up X (Twitter) user profiles by username or ID",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"users":{"type":"array","items":{"type":"string"},"minItems":1,"description":"Usernames (without @), or user IDs prefixed with id:"}},"required":["users"]}`),
+	},
+	{
+		Name:        "search_spaces",
+		Description: "Search live and scheduled Spaces on X (Twitter)",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"query":{"type":"string","description":"Search query"},"state":{"type":"string","enum":["live","scheduled","all"],"description":"State of spaces to search (default all)"},"count":{"type":"integer","minimum":1,"maximum":100,"description":"Number of spaces to fetch (max 100)"}},"required":["query"]}`),
+	},
+	{
+		Name:        "get_space",
+		Description: "Look up a Space on X (Twitter) by ID",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"space_id":{"type":"string","description":"Space ID"}},"required":["space_id"]}`),
+	},
+	{
+		Name:        "get_user_spaces",
+		Description: "Get Spaces created by a user on X (Twitter)",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"username":{"type":"string","description":"Twitter username (without @)"}},"required":["username"]}`),
+	},
 	{
 		Name:        "post_tweet",
 		Description: "Post a new tweet on X (Twitter)",
-		InputSchema: json.RawMessage(`{"type":"object","properties":{"text":{"type":"string","description":"Tweet text"},"reply_to":{"type":"string","description":"Tweet ID to reply to"}},"required":["text"]}`),
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"text":{"type":"string","description":"Tweet text"},"reply_to":{"type":"string","description":"Tweet ID to reply to"},"quote":{"type":"string","description":"Tweet ID or URL to quote"},"reply_settings":{"type":"string","enum":["following","mentionedUsers","subscribers"],"description":"Limit who can reply"}},"required":["text"]}`),
 	},
 	{
 		Name:        "like_tweet",
@@ -178,8 +224,26 @@ func (app *App) handleToolCall(params json.RawMessage) (*mcpToolResult, *jsonrpc
 		return app.mcpGetMentions(req.Arguments)
 	case "get_user_tweets":
 		return app.mcpGetUserTweets(req.Arguments)
+	case "get_liked_tweets":
+		return app.mcpGetLikedTweets(req.Arguments)
 	case "get_list_tweets":
 		return app.mcpGetListTweets(req.Arguments)
+	case "get_thread":
+		return app.mcpGetThread(req.Arguments)
+	case "get_liking_users":
+		return app.mcpGetEngagingUsers(req.Arguments, app.fetchLikingUsers)
+	case "get_retweeted_by":
+		return app.mcpGetEngagingUsers(req.Arguments, app.fetchRetweetedBy)
+	case "get_quote_tweets":
+		return app.mcpGetQuoteTweets(req.Arguments)
+	case "get_user_profile":
+		return app.mcpGetUserProfile(req.Arguments)
+	case "search_spaces":
+		return app.mcpSearchSpaces(req.Arguments)
+	case "get_space":
+		return app.mcpGetSpace(req.Arguments)
+	case "get_user_spaces":
+		return app.mcpGetUserSpaces(req.Arguments)
 	case "post_tweet":
 		return app.mcpPostTweet(req.Arguments)
 	case "like_tweet":
@@ -226,8 +290,9 @@ func (app *App) mcpGetTimeline(args json.RawMessage) (*mcpToolResult, *jsonrpcEr
 
 func (app *App) mcpSearchTweets(args json.RawMessage) (*mcpToolResult, *jsonrpcError) {
 	var p struct {
-		Query string `json:"query"`
-		Count int    `json:"count"`
+		Query   string `json:"query"`
+		Count   int    `json:"count"`
+		Archive bool   `json:"archive"`
 	}
 	if err := json.Unmarshal(args, &p); err != nil {
 		return nil, &jsonrpcError{Code: -32602, Message: "invalid arguments"}
@@ -236,7 +301,7 @@ func (app *App) mcpSearchTweets(args json.RawMessage) (*mcpToolResult, *jsonrpcE
 		return nil, &jsonrpcError{Code: -32602, Message: "query is required"}
 	}
 
-	res, err := app.fetchSearchTweets(p.Query, countString(p.Count), "", "", "")
+	res, err := app.fetchSearchTweets(p.Query, countString(p.Count), "", "", "", p.Archive)
 	if err != nil {
 		return errorResult(err), nil
 	}
@@ -275,6 +340,26 @@ func (app *App) mcpGetUserTweets(args json.RawMessage) (*mcpToolResult, *jsonrpc
 	return textResult(formatTweetsText(res)), nil
 }
 
+func (app *App) mcpGetLikedTweets(args json.RawMessage) (*mcpToolResult, *jsonrpcError) {
+	var p struct {
+		Username string `json:"username"`
+		Count    int    `json:"count"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, &jsonrpcError{Code: -32602, Message: "invalid arguments"}
+	}
+
+	user := p.Username
+	if user != "" && !strings.HasPrefix(user, "@") {
+		user = "@" + user
+	}
+	res, err := app.fetchLikedTweets(user, countString(p.Count))
+	if err != nil {
+		return errorResult(err), nil
+	}
+	return textResult(formatTweetsText(res)), nil
+}
+
 func (app *App) mcpGetListTweets(args json.RawMessage) (*mcpToolResult, *jsonrpcError) {
 	var p struct {
 		List  string `json:"list"`
@@ -294,10 +379,162 @@ func (app *App) mcpGetListTweets(args json.RawMessage) (*mcpToolResult, *jsonrpc
 	return textResult(formatTweetsText(res)), nil
 }
 
+func (app *App) mcpGetThread(args json.RawMessage) (*mcpToolResult, *jsonrpcError) {
+	var p struct {
+		TweetID string `json:"tweet_id"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, &jsonrpcError{Code: -32602, Message: "invalid arguments"}
+	}
+	if p.TweetID == "" {
+		return nil, &jsonrpcError{Code: -32602, Message: "tweet_id is required"}
+	}
+	tweetID, err := parseTweetID(p.TweetID)
+	if err != nil {
+		return nil, &jsonrpcError{Code: -32602, Message: err.Error()}
+	}
+
+	res, rootID, err := app.fetchThread(tweetID)
+	if err != nil {
+		return errorResult(err), nil
+	}
+	tweetMap := make(map[string]V2Tweet)
+	for _, t := range res.Includes.Tweets {
+		tweetMap[t.ID] = t
+	}
+	userMap := make(map[string]V2User)
+	for _, u := range res.Includes.Users {
+		userMap[u.ID] = u
+	}
+	return textResult(formatThreadText(buildThread(res, rootID), tweetMap, userMap)), nil
+}
+
+func (app *App) mcpGetEngagingUsers(args json.RawMessage, fetch func(string, string) ([]V2User, error)) (*mcpToolResult, *jsonrpcError) {
+	var p struct {
+		TweetID string `json:"tweet_id"`
+		Count   int    `json:"count"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, &jsonrpcError{Code: -32602, Message: "invalid arguments"}
+	}
+	if p.TweetID == "" {
+		return nil, &jsonrpcError{Code: -32602, Message: "tweet_id is required"}
+	}
+	tweetID, err := parseTweetID(p.TweetID)
+	if err != nil {
+		return nil, &jsonrpcError{Code: -32602, Message: err.Error()}
+	}
+
+	users, err := fetch(tweetID, countString(p.Count))
+	if err != nil {
+		return errorResult(err), nil
+	}
+	return textResult(formatUsersText(V2UsersResponse{Data: users})), nil
+}
+
+func (app *App) mcpGetQuoteTweets(args json.RawMessage) (*mcpToolResult, *jsonrpcError) {
+	var p struct {
+		TweetID string `json:"tweet_id"`
+		Count   int    `json:"count"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, &jsonrpcError{Code: -32602, Message: "invalid arguments"}
+	}
+	if p.TweetID == "" {
+		return nil, &jsonrpcError{Code: -32602, Message: "tweet_id is required"}
+	}
+	tweetID, err := parseTweetID(p.TweetID)
+	if err != nil {
+		return nil, &jsonrpcError{Code: -32602, Message: err.Error()}
+	}
+
+	res, err := app.fetchQuoteTweets(tweetID, countString(p.Count))
+	if err != nil {
+		return errorResult(err), nil
+	}
+	return textResult(formatTweetsText(res)), nil
+}
+
+func (app *App) mcpGetUserProfile(args json.RawMessage) (*mcpToolResult, *jsonrpcError) {
+	var p struct {
+		Users []string `json:"users"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, &jsonrpcError{Code: -32602, Message: "invalid arguments"}
+	}
+	if len(p.Users) == 0 {
+		return nil, &jsonrpcError{Code: -32602, Message: "users is required"}
+	}
+
+	res, err := app.fetchUserProfiles(p.Users)
+	if err != nil {
+		return errorResult(err), nil
+	}
+	return textResult(formatUsersText(res)), nil
+}
+
+func (app *App) mcpSearchSpaces(args json.RawMessage) (*mcpToolResult, *jsonrpcError) {
+	var p struct {
+		Query string `json:"query"`
+		State string `json:"state"`
+		Count int    `json:"count"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, &jsonrpcError{Code: -32602, Message: "invalid arguments"}
+	}
+	if p.Query == "" {
+		return nil, &jsonrpcError{Code: -32602, Message: "query is required"}
+	}
+
+	res, err := app.searchSpaces(p.Query, p.State, countString(p.Count))
+	if err != nil {
+		return errorResult(err), nil
+	}
+	return textResult(formatSpacesText(res)), nil
+}
+
+func (app *App) mcpGetSpace(args json.RawMessage) (*mcpToolResult, *jsonrpcError) {
+	var p struct {
+		SpaceID string `json:"space_id"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, &jsonrpcError{Code: -32602, Message: "invalid arguments"}
+	}
+	if p.SpaceID == "" {
+		return nil, &jsonrpcError{Code: -32602, Message: "space_id is required"}
+	}
+
+	res, err := app.fetchSpace(p.SpaceID)
+	if err != nil {
+		return errorResult(err), nil
+	}
+	return textResult(formatSpacesText(res)), nil
+}
+
+func (app *App) mcpGetUserSpaces(args json.RawMessage) (*mcpToolResult, *jsonrpcError) {
+	var p struct {
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, &jsonrpcError{Code: -32602, Message: "invalid arguments"}
+	}
+	if p.Username == "" {
+		return nil, &jsonrpcError{Code: -32602, Message: "username is required"}
+	}
+
+	res, err := app.fetchUserSpaces("@" + strings.TrimPrefix(p.Username, "@"))
+	if err != nil {
+		return errorResult(err), nil
+	}
+	return textResult(formatSpacesText(res)), nil
+}
+
 func (app *App) mcpPostTweet(args json.RawMessage) (*mcpToolResult, *jsonrpcError) {
 	var p struct {
-		Text    string `json:"text"`
-		ReplyTo string `json:"reply_to"`
+		Text          string `json:"text"`
+		ReplyTo       string `json:"reply_to"`
+		Quote         string `json:"quote"`
+		ReplySettings string `json:"reply_settings"`
 	}
 	if err := json.Unmarshal(args, &p); err != nil {
 		return nil, &jsonrpcError{Code: -32602, Message: "invalid arguments"}
@@ -306,7 +543,19 @@ func (app *App) mcpPostTweet(args json.RawMessage) (*mcpToolResult, *jsonrpcErro
 		return nil, &jsonrpcError{Code: -32602, Message: "text is required"}
 	}
 
-	id, err := app.createTweet(p.Text, p.ReplyTo, nil)
+	opts := tweetOptions{InReplyTo: p.ReplyTo, ReplySettings: p.ReplySettings}
+	if p.Quote != "" {
+		id, err := parseTweetID(p.Quote)
+		if err != nil {
+			return nil, &jsonrpcError{Code: -32602, Message: err.Error()}
+		}
+		opts.QuoteTweetID = id
+	}
+	if err := validateTweetOptions(p.Text, opts); err != nil {
+		return nil, &jsonrpcError{Code: -32602, Message: err.Error()}
+	}
+
+	id, err := app.createTweet(p.Text, opts)
 	if err != nil {
 		return errorResult(err), nil
 	}
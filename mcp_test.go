@@ -121,3 +121,73 @@ func TestMcpToolsSchemaParses(t *testing.T) {
 		}
 	}
 }
+
+func TestMcpGetUserProfileMissingUsers(t *testing.T) {
+	app := &App{}
+	_, rpcErr := app.mcpGetUserProfile(json.RawMessage(`{}`))
+	if rpcErr == nil || rpcErr.Code != -32602 {
+		t.Fatalf("expected -32602 error, got %+v", rpcErr)
+	}
+}
+
+func TestMcpGetThreadMissingID(t *testing.T) {
+	app := &App{}
+	_, rpcErr := app.mcpGetThread(json.RawMessage(`{}`))
+	if rpcErr == nil || rpcErr.Code != -32602 {
+		t.Fatalf("expected -32602 error, got %+v", rpcErr)
+	}
+}
+
+func TestMcpGetThreadInvalidID(t *testing.T) {
+	app := &App{}
+	_, rpcErr := app.mcpGetThread(json.RawMessage(`{"tweet_id":"not-a-tweet"}`))
+	if rpcErr == nil || rpcErr.Code != -32602 {
+		t.Fatalf("expected -32602 error, got %+v", rpcErr)
+	}
+}
+
+func TestMcpPostTweetInvalidQuote(t *testing.T) {
+	app := &App{}
+	_, rpcErr := app.mcpPostTweet(json.RawMessage(`{"text":"hi","quote":"not-a-tweet"}`))
+	if rpcErr == nil || rpcErr.Code != -32602 {
+		t.Fatalf("expected -32602 error, got %+v", rpcErr)
+	}
+}
+
+func TestMcpPostTweetInvalidReplySettings(t *testing.T) {
+	app := &App{}
+	_, rpcErr := app.mcpPostTweet(json.RawMessage(`{"text":"hi","reply_settings":"everyone"}`))
+	if rpcErr == nil || rpcErr.Code != -32602 {
+		t.Fatalf("expected -32602 error, got %+v", rpcErr)
+	}
+}
+
+func TestMcpGetEngagingUsersMissingID(t *testing.T) {
+	app := &App{}
+	_, rpcErr := app.mcpGetEngagingUsers(json.RawMessage(`{}`), app.fetchLikingUsers)
+	if rpcErr == nil || rpcErr.Code != -32602 {
+		t.Fatalf("expected -32602 error, got %+v", rpcErr)
+	}
+}
+
+func TestMcpGetQuoteTweetsMissingID(t *testing.T) {
+	app := &App{}
+	_, rpcErr := app.mcpGetQuoteTweets(json.RawMessage(`{}`))
+	if rpcErr == nil || rpcErr.Code != -32602 {
+		t.Fatalf("expected -32602 error, got %+v", rpcErr)
+	}
+}
+
+func TestMcpSpacesMissingArguments(t *testing.T) {
+	app := &App{}
+	for name, fn := range map[string]func(json.RawMessage) (*mcpToolResult, *jsonrpcError){
+		"search_spaces":   app.mcpSearchSpaces,
+		"get_space":       app.mcpGetSpace,
+		"get_user_spaces": app.mcpGetUserSpaces,
+	} {
+		_, rpcErr := fn(json.RawMessage(`{}`))
+		if rpcErr == nil || rpcErr.Code != -32602 {
+			t.Errorf("%s: expected -32602 error, got %+v", name, rpcErr)
+		}
+	}
+}
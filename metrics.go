@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tweetSortKeys are the metrics tweets can be sorted by with -sort.
+var tweetSortKeys = map[string]func(m V2TweetMetrics) int{
+	"likes":       func(m V2TweetMetrics) int { return m.LikeCount },
+	"retweets":    func(m V2TweetMetrics) int { return m.RetweetCount },
+	"replies":     func(m V2TweetMetrics) int { return m.ReplyCount },
+	"quotes":      func(m V2TweetMetrics) int { return m.QuoteCount },
+	"bookmarks":   func(m V2TweetMetrics) int { return m.BookmarkCount },
+	"impressions": func(m V2TweetMetrics) int { return m.ImpressionCount },
+	"engagement": func(m V2TweetMetrics) int {
+		return m.LikeCount + m.RetweetCount + m.ReplyCount + m.QuoteCount + m.BookmarkCount
+	},
+}
+
+func sortKeyNames() string {
+	names := make([]string, 0, len(tweetSortKeys))
+	for name := range tweetSortKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// sortTweets orders tweets by the metric key, highest first. Tweets with
+// the same value keep their order.
+func sortTweets(tweets []V2Tweet, key string) error {
+	metric, ok := tweetSortKeys[key]
+	if !ok {
+		return fmt.Errorf("unknown sort key %q: use %s", key, sortKeyNames())
+	}
+	value := func(t V2Tweet) int {
+		if t.PublicMetrics == nil {
+			return -1
+		}
+		return metric(*t.PublicMetrics)
+	}
+	sort.SliceStable(tweets, func(i, j int) bool {
+		return value(tweets[i]) > value(tweets[j])
+	})
+	return nil
+}
+
+// formatMetrics returns the lines describing the metrics of tweet in the
+// verbose mode.
+func formatMetrics(tweet V2Tweet) []string {
+	var lines []string
+	if m := tweet.PublicMetrics; m != nil {
+		lines = append(lines, fmt.Sprintf("%d likes, %d retweets, %d replies, %d quotes, %d bookmarks, %d impressions",
+			m.LikeCount, m.RetweetCount, m.ReplyCount, m.QuoteCount, m.BookmarkCount, m.ImpressionCount))
+	}
+	if m := tweet.NonPublicMetrics; m != nil {
+		lines = append(lines, fmt.Sprintf("non-public: %d impressions, %d engagements, %d link clicks, %d profile clicks",
+			m.ImpressionCount, m.Engagements, m.URLLinkClicks, m.UserProfileClicks))
+	}
+	if m := tweet.OrganicMetrics; m != nil {
+		lines = append(lines, fmt.Sprintf("organic: %d impressions, %d likes, %d retweets, %d replies, %d link clicks, %d profile clicks",
+			m.ImpressionCount, m.LikeCount, m.RetweetCount, m.ReplyCount, m.URLLinkClicks, m.UserProfileClicks))
+	}
+	return lines
+}
+
+// ownMetricsAge is how long X keeps the non-public and organic metrics of
+// a tweet.
+const ownMetricsAge = 30 * 24 * time.Hour
+
+// ownMetricsIDs returns the IDs of the tweets posted by userID that are
+// recent enough to have non-public and organic metrics.
+func ownMetricsIDs(tweets []V2Tweet, userID string, now time.Time) []string {
+	var ids []string
+	for _, t := range tweets {
+		if t.AuthorID != userID {
+			continue
+		}
+		if created, err := time.Parse(time.RFC3339, t.CreatedAt); err == nil && now.Sub(created) > ownMetricsAge {
+			continue
+		}
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+// fetchOwnMetrics adds the non-public and organic metrics to the tweets in
+// res that were posted by the authenticated user in the last 30 days. These
+// metrics need a token that allows them, so failures are ignored and the
+// tweets keep only their public metrics.
+func (app *App) fetchOwnMetrics(res *V2TweetsResponse) {
+	myID, err := app.getMyID()
+	if err != nil {
+		return
+	}
+	ids := ownMetricsIDs(res.Data, myID, time.Now())
+
+	metrics := make(map[string]V2Tweet)
+	for len(ids) > 0 {
+		n := min(len(ids), maxLookupIDs)
+		var page V2TweetsResponse
+		err := app.callGet("https://api.twitter.com/2/tweets", map[string]string{
+			"ids":          strings.Join(ids[:n], ","),
+			"tweet.fields": "non_public_metrics,organic_metrics",
+		}, &page)
+		ids = ids[n:]
+		if err != nil {
+			continue
+		}
+		for _, t := range page.Data {
+			metrics[t.ID] = t
+		}
+	}
+	mergeMetrics(res.Data, metrics)
+}
+
+func mergeMetrics(tweets []V2Tweet, metrics map[string]V2Tweet) {
+	for i := range tweets {
+		if m, ok := metrics[tweets[i].ID]; ok {
+			tweets[i].NonPublicMetrics = m.NonPublicMetrics
+			tweets[i].OrganicMetrics = m.OrganicMetrics
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSortTweets(t *testing.T) {
+	tweets := []V2Tweet{
+		{ID: "1", PublicMetrics: &V2TweetMetrics{LikeCount: 1, RetweetCount: 9}},
+		{ID: "2"},
+		{ID: "3", PublicMetrics: &V2TweetMetrics{LikeCount: 5}},
+		{ID: "4", PublicMetrics: &V2TweetMetrics{LikeCount: 5, ReplyCount: 1}},
+	}
+	ids := func() []string {
+		var ids []string
+		for _, t := range tweets {
+			ids = append(ids, t.ID)
+		}
+		return ids
+	}
+
+	if err := sortTweets(tweets, "likes"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(); !reflect.DeepEqual(got, []string{"3", "4", "1", "2"}) {
+		t.Errorf("sorted by likes = %v", got)
+	}
+	if err := sortTweets(tweets, "engagement"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(); !reflect.DeepEqual(got, []string{"1", "4", "3", "2"}) {
+		t.Errorf("sorted by engagement = %v", got)
+	}
+	if err := sortTweets(tweets, "views"); err == nil {
+		t.Error("expected error for unknown sort key")
+	}
+}
+
+func TestFormatMetrics(t *testing.T) {
+	tweet := V2Tweet{
+		PublicMetrics:    &V2TweetMetrics{LikeCount: 5, RetweetCount: 2, ImpressionCount: 100},
+		NonPublicMetrics: &V2NonPublicMetrics{ImpressionCount: 100, Engagements: 12, URLLinkClicks: 3, UserProfileClicks: 1},
+	}
+	want := []string{
+		"5 likes, 2 retweets, 0 replies, 0 quotes, 0 bookmarks, 100 impressions",
+		"non-public: 100 impressions, 12 engagements, 3 link clicks, 1 profile clicks",
+	}
+	if got := formatMetrics(tweet); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := formatMetrics(V2Tweet{}); len(got) != 0 {
+		t.Errorf("got %q for a tweet without metrics", got)
+	}
+}
+
+func TestMergeMetrics(t *testing.T) {
+	tweets := []V2Tweet{{ID: "1"}, {ID: "2"}}
+	var page V2TweetsResponse
+	err := json.Unmarshal([]byte(`{"data":[{"id":"2","non_public_metrics":{"impression_count":7,"url_link_clicks":1,"user_profile_clicks":0,"engagements":2},"organic_metrics":{"impression_count":7,"like_count":1,"reply_count":0,"retweet_count":0,"url_link_clicks":1,"user_profile_clicks":0}}]}`), &page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mergeMetrics(tweets, map[string]V2Tweet{"2": page.Data[0]})
+	if tweets[0].NonPublicMetrics != nil || tweets[0].OrganicMetrics != nil {
+		t.Errorf("tweet 1 got private metrics: %+v", tweets[0])
+	}
+	if tweets[1].NonPublicMetrics == nil || tweets[1].NonPublicMetrics.Engagements != 2 || tweets[1].OrganicMetrics == nil || tweets[1].OrganicMetrics.LikeCount != 1 {
+		t.Errorf("tweet 2 = %+v", tweets[1])
+	}
+}
+
+func TestWriteTweetTablePrivateMetrics(t *testing.T) {
+	res := V2TweetsResponse{Data: []V2Tweet{{
+		ID:               "1",
+		PublicMetrics:    &V2TweetMetrics{},
+		NonPublicMetrics: &V2NonPublicMetrics{Engagements: 12, URLLinkClicks: 3, UserProfileClicks: 1},
+	}}}
+	row := tweetRow(newTweetViews(res)[0])
+	if got := row[len(row)-3:]; !reflect.DeepEqual(got, []string{"12", "3", "1"}) {
+		t.Errorf("private metric columns = %q", got)
+	}
+}
+
+func TestOwnMetricsIDs(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tweets := []V2Tweet{
+		{ID: "1", AuthorID: "me", CreatedAt: "2026-10-17T12:00:00.000Z"},
+		{ID: "2", AuthorID: "other", CreatedAt: "2026-10-17T12:00:00.000Z"},
+		{ID: "3", AuthorID: "me", CreatedAt: "2026-09-01T12:00:00.000Z"},
+		{ID: "4", AuthorID: "me"},
+	}
+	if got := ownMetricsIDs(tweets, "me", now); !reflect.DeepEqual(got, []string{"1", "4"}) {
+		t.Errorf("got %v, want [1 4]", got)
+	}
+}
+
+func TestTemplateUsesPrivateMetrics(t *testing.T) {
+	tests := []struct {
+		format string
+		want   bool
+	}{
+		{"{{.Author.Username}}: {{.Text}}", false},
+		{"{{.Metrics.LikeCount}}", false},
+		{"{{with .NonPublic}}{{.Engagements}}{{end}}", true},
+		{"{{.Organic.LikeCount}}", true},
+		{"{{.Tweet.NonPublicMetrics}}", true},
+	}
+	for _, tt := range tests {
+		tmpl, err := parseTweetTemplate(tt.format, nil)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %v", tt.format, err)
+		}
+		if got := templateUsesPrivateMetrics(tmpl); got != tt.want {
+			t.Errorf("templateUsesPrivateMetrics(%q) = %v, want %v", tt.format, got, tt.want)
+		}
+	}
+}
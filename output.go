@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tweetColumns are the columns of the tabular -o formats. New columns are
+// only ever appended, so scripts reading them keep working.
+var tweetColumns = []string{
+	"id",
+	"created_at",
+	"author_username",
+	"author_name",
+	"text",
+	"url",
+	"urls",
+	"retweet_count",
+	"reply_count",
+	"like_count",
+	"quote_count",
+	"bookmark_count",
+	"impression_count",
+	"engagements",
+	"url_link_clicks",
+	"user_profile_clicks",
+}
+
+var tweetOutputFormats = []string{"csv", "tsv", "ndjson", "markdown", "html"}
+
+var linkPattern = regexp.MustCompile(`https?://\S+`)
+
+func isTweetOutputFormat(format string) bool {
+	for _, f := range tweetOutputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// tweetLinks returns the links in the text of v, separated by spaces.
+// Links are expanded when the tweet has entities.
+func tweetLinks(v tweetView) string {
+	text, entities := longText(v.Tweet)
+	if entities != nil {
+		return strings.Join(entityLinks(entities), " ")
+	}
+	return strings.Join(linkPattern.FindAllString(text, -1), " ")
+}
+
+// tweetValues returns the values of the tweetColumns of v. Counts are ints
+// and the non-public metrics *int, nil when the tweet has none.
+func tweetValues(v tweetView) []any {
+	created := ""
+	if !v.CreatedAt.IsZero() {
+		created = v.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return []any{
+		v.ID,
+		created,
+		v.Author.Username,
+		v.Author.Name,
+		v.Text,
+		v.URL,
+		tweetLinks(v),
+		v.Metrics.RetweetCount,
+		v.Metrics.ReplyCount,
+		v.Metrics.LikeCount,
+		v.Metrics.QuoteCount,
+		v.Metrics.BookmarkCount,
+		v.Metrics.ImpressionCount,
+		privateMetric(v.NonPublic, func(m V2NonPublicMetrics) int { return m.Engagements }),
+		privateMetric(v.NonPublic, func(m V2NonPublicMetrics) int { return m.URLLinkClicks }),
+		privateMetric(v.NonPublic, func(m V2NonPublicMetrics) int { return m.UserProfileClicks }),
+	}
+}
+
+// privateMetric returns a non-public metric, or nil when the tweet has none,
+// as for tweets of other users.
+func privateMetric(m *V2NonPublicMetrics, metric func(V2NonPublicMetrics) int) *int {
+	if m == nil {
+		return nil
+	}
+	n := metric(*m)
+	return &n
+}
+
+// tweetRow returns the values of the tweetColumns of v as text. Missing
+// non-public metrics are empty.
+func tweetRow(v tweetView) []string {
+	values := tweetValues(v)
+	row := make([]string, len(values))
+	for i, value := range values {
+		switch value := value.(type) {
+		case string:
+			row[i] = value
+		case int:
+			row[i] = strconv.Itoa(value)
+		case *int:
+			if value != nil {
+				row[i] = strconv.Itoa(*value)
+			}
+		}
+	}
+	return row
+}
+
+var (
+	tsvReplacer      = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+	markdownReplacer = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+)
+
+// writeTweetTable writes views in format, one of tweetOutputFormats. header
+// is false when appending to earlier output, as in polling mode. HTML is
+// always written as a complete table.
+func writeTweetTable(w io.Writer, format string, views []tweetView, header bool) error {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		if header {
+			cw.Write(tweetColumns)
+		}
+		for _, v := range views {
+			cw.Write(tweetRow(v))
+		}
+		cw.Flush()
+		return cw.Error()
+	case "tsv":
+		if header {
+			fmt.Fprintln(w, strings.Join(tweetColumns, "\t"))
+		}
+		for _, v := range views {
+			row := tweetRow(v)
+			for i := range row {
+				row[i] = tsvReplacer.Replace(row[i])
+			}
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, v := range views {
+			values := tweetValues(v)
+			obj := make(map[string]any, len(values))
+			for i, c := range tweetColumns {
+				obj[c] = values[i]
+			}
+			if err := enc.Encode(obj); err != nil {
+				return err
+			}
+		}
+	case "markdown":
+		if header {
+			fmt.Fprintln(w, "| "+strings.Join(tweetColumns, " | ")+" |")
+			fmt.Fprintln(w, "|"+strings.Repeat(" --- |", len(tweetColumns)))
+		}
+		for _, v := range views {
+			row := tweetRow(v)
+			for i := range row {
+				row[i] = markdownReplacer.Replace(row[i])
+			}
+			fmt.Fprintln(w, "| "+strings.Join(row, " | ")+" |")
+		}
+	case "html":
+		fmt.Fprintln(w, "<table>")
+		fmt.Fprint(w, "<tr>")
+		for _, c := range tweetColumns {
+			fmt.Fprint(w, "<th>"+c+"</th>")
+		}
+		fmt.Fprintln(w, "</tr>")
+		for _, v := range views {
+			fmt.Fprint(w, "<tr>")
+			for i, cell := range tweetRow(v) {
+				cell = html.EscapeString(cell)
+				switch tweetColumns[i] {
+				case "text":
+					cell = strings.ReplaceAll(cell, "\n", "<br>")
+				case "url":
+					cell = `<a href="` + cell + `">` + cell + `</a>`
+				}
+				fmt.Fprint(w, "<td>"+cell+"</td>")
+			}
+			fmt.Fprintln(w, "</tr>")
+		}
+		fmt.Fprintln(w, "</table>")
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+	return nil
+}
+
+// tweetJSONVersion is the version of the schema written by -o json. It is
+// only increased when a field is removed or changes its meaning; new fields
+// may be added within a version.
+const tweetJSONVersion = 1
+
+// jsonTweet is a tweet as written by -o json, with its author, referenced
+// tweets and media resolved from the includes of the response.
+type jsonTweet struct {
+	Version           int                   `json:"version"`
+	ID                string                `json:"id"`
+	Text              string                `json:"text"`
+	CreatedAt         string                `json:"created_at"`
+	URL               string                `json:"url"`
+	ConversationID    string                `json:"conversation_id,omitempty"`
+	InReplyToUserID   string                `json:"in_reply_to_user_id,omitempty"`
+	Author            *V2User               `json:"author"`
+	Metrics           *V2TweetMetrics       `json:"metrics,omitempty"`
+	NonPublicMetrics  *V2NonPublicMetrics   `json:"non_public_metrics,omitempty"`
+	OrganicMetrics    *V2OrganicMetrics     `json:"organic_metrics,omitempty"`
+	ReferencedTweets  []jsonReferencedTweet `json:"referenced_tweets"`
+	Media             []V2Media             `json:"media"`
+	Polls             []V2Poll              `json:"polls,omitempty"`
+	EditHistoryTweets []string              `json:"edit_history_tweet_ids,omitempty"`
+	Entities          *V2Entities           `json:"entities,omitempty"`
+}
+
+type jsonReferencedTweet struct {
+	Type      string  `json:"type"`
+	ID        string  `json:"id"`
+	Text      string  `json:"text,omitempty"`
+	CreatedAt string  `json:"created_at,omitempty"`
+	URL       string  `json:"url"`
+	Author    *V2User `json:"author"`
+	// Entities are those of the text, so they match its offsets.
+	Entities *V2Entities `json:"entities,omitempty"`
+}
+
+func newJSONTweets(res V2TweetsResponse) []jsonTweet {
+	userMap := make(map[string]V2User)
+	for _, u := range res.Includes.Users {
+		userMap[u.ID] = u
+	}
+	tweetMap := make(map[string]V2Tweet)
+	for _, t := range res.Includes.Tweets {
+		tweetMap[t.ID] = t
+	}
+	pollMap := make(map[string]V2Poll)
+	for _, p := range res.Includes.Polls {
+		pollMap[p.ID] = p
+	}
+	mediaMap := make(map[string]V2Media)
+	for _, m := range res.Includes.Media {
+		mediaMap[m.MediaKey] = m
+	}
+	findUser := func(id string) *V2User {
+		if u, ok := userMap[id]; ok {
+			return &u
+		}
+		return nil
+	}
+
+	tweets := make([]jsonTweet, 0, len(res.Data))
+	for _, tweet := range res.Data {
+		text, entities := longText(tweet)
+		t := jsonTweet{
+			Version:           tweetJSONVersion,
+			ID:                tweet.ID,
+			Text:              html.UnescapeString(text),
+			CreatedAt:         tweet.CreatedAt,
+			ConversationID:    tweet.ConversationID,
+			InReplyToUserID:   tweet.InReplyToUserID,
+			Author:            findUser(tweet.AuthorID),
+			Metrics:           tweet.PublicMetrics,
+			NonPublicMetrics:  tweet.NonPublicMetrics,
+			OrganicMetrics:    tweet.OrganicMetrics,
+			ReferencedTweets:  []jsonReferencedTweet{},
+			Media:             []V2Media{},
+			EditHistoryTweets: tweet.EditHistory,
+			Entities:          entities,
+		}
+		username := ""
+		if t.Author != nil {
+			username = t.Author.Username
+		}
+		t.URL = tweetURL(username, tweet.ID)
+
+		for _, ref := range tweet.ReferencedTweets {
+			r := jsonReferencedTweet{Type: ref.Type, ID: ref.ID}
+			username := ""
+			if rt, ok := tweetMap[ref.ID]; ok {
+				text, entities := longText(rt)
+				r.Text = html.UnescapeString(text)
+				r.Entities = entities
+				r.CreatedAt = rt.CreatedAt
+				r.Author = findUser(rt.AuthorID)
+				if r.Author != nil {
+					username = r.Author.Username
+				}
+			}
+			r.URL = tweetURL(username, ref.ID)
+			t.ReferencedTweets = append(t.ReferencedTweets, r)
+		}
+		if tweet.Attachments != nil {
+			for _, key := range tweet.Attachments.MediaKeys {
+				if m, ok := mediaMap[key]; ok {
+					t.Media = append(t.Media, m)
+				} else {
+					t.Media = append(t.Media, V2Media{MediaKey: key})
+				}
+			}
+			for _, id := range tweet.Attachments.PollIDs {
+				if p, ok := pollMap[id]; ok {
+					t.Polls = append(t.Polls, p)
+				}
+			}
+		}
+		tweets = append(tweets, t)
+	}
+	return tweets
+}
+
+// writeJSONTweets writes the tweets in res as one jsonTweet per line.
+func writeJSONTweets(w io.Writer, res V2TweetsResponse) error {
+	enc := json.NewEncoder(w)
+	for _, t := range newJSONTweets(res) {
+		if err := enc.Encode(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTweetViews() []tweetView {
+	res := V2TweetsResponse{
+		Data: []V2Tweet{{
+			ID:            "1",
+			Text:          "a | b\tc\nsee https://t.co/x <b>",
+			AuthorID:      "10",
+			CreatedAt:     "2026-10-18T12:00:00.000Z",
+			PublicMetrics: &V2TweetMetrics{LikeCount: 5, RetweetCount: 2},
+		}},
+	}
+	res.Includes.Users = []V2User{{ID: "10", Username: "alice", Name: "Alice"}}
+	return newTweetViews(res)
+}
+
+func TestWriteTweetTable(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"csv", []string{
+			"id,created_at,author_username,author_name,text,url,urls,retweet_count,reply_count,like_count,quote_count,bookmark_count,impression_count,engagements,url_link_clicks,user_profile_clicks",
+			"1,2026-10-18T12:00:00Z,alice,Alice,\"a | b\tc",
+			"see https://t.co/x <b>\",https://x.com/alice/status/1,https://t.co/x,2,0,5,0,0,0,,,",
+		}},
+		{"tsv", []string{
+			"id\tcreated_at\tauthor_username\tauthor_name\ttext\turl\turls\tretweet_count\treply_count\tlike_count\tquote_count\tbookmark_count\timpression_count\tengagements\turl_link_clicks\tuser_profile_clicks",
+			"1\t2026-10-18T12:00:00Z\talice\tAlice\ta | b c see https://t.co/x <b>\thttps://x.com/alice/status/1\thttps://t.co/x\t2\t0\t5\t0\t0\t0\t\t\t",
+		}},
+		{"markdown", []string{
+			"| id | created_at | author_username | author_name | text | url | urls | retweet_count | reply_count | like_count | quote_count | bookmark_count | impression_count | engagements | url_link_clicks | user_profile_clicks |",
+			"| --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- |",
+			"| 1 | 2026-10-18T12:00:00Z | alice | Alice | a \\| b\tc<br>see https://t.co/x <b> | https://x.com/alice/status/1 | https://t.co/x | 2 | 0 | 5 | 0 | 0 | 0 |  |  |  |",
+		}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeTweetTable(&buf, tt.format, testTweetViews(), true); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.format, err)
+		}
+		want := strings.Join(tt.want, "\n") + "\n"
+		if got := buf.String(); got != want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.format, got, want)
+		}
+	}
+}
+
+func TestWriteTweetTableWithoutHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeTweetTable(&buf, "csv", testTweetViews(), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.HasPrefix(buf.String(), "id,") {
+		t.Errorf("header written: %q", buf.String())
+	}
+}
+
+func TestWriteTweetTableNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeTweetTable(&buf, "ndjson", testTweetViews(), true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var row map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &row); err != nil {
+		t.Fatalf("cannot decode %q: %v", buf.String(), err)
+	}
+	if len(row) != len(tweetColumns) || row["author_username"] != "alice" {
+		t.Errorf("got %v", row)
+	}
+	if row["like_count"] != 5.0 || row["engagements"] != nil {
+		t.Errorf("metrics not written as numbers: like_count=%#v engagements=%#v", row["like_count"], row["engagements"])
+	}
+}
+
+func TestWriteTweetTableHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeTweetTable(&buf, "html", testTweetViews(), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{"<table>", "<th>id</th>", "&lt;b&gt;", `<a href="https://x.com/alice/status/1">`, "</table>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q not found in %q", want, got)
+		}
+	}
+	if err := writeTweetTable(&buf, "xml", nil, true); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestWriteJSONTweets(t *testing.T) {
+	res := V2TweetsResponse{
+		Data: []V2Tweet{{
+			ID:               "2",
+			Text:             "look &amp; see",
+			AuthorID:         "10",
+			ReferencedTweets: []V2ReferencedTweet{{Type: "quoted", ID: "9"}, {Type: "replied_to", ID: "8"}},
+			Attachments:      &V2Attachments{MediaKeys: []string{"3_1"}},
+		}},
+	}
+	res.Includes.Users = []V2User{{ID: "10", Username: "alice"}, {ID: "11", Username: "bob"}}
+	res.Includes.Tweets = []V2Tweet{{
+		ID:       "9",
+		Text:     "quoted",
+		AuthorID: "11",
+		Entities: &V2Entities{Hashtags: []V2TagEntity{{Tag: "quoted"}}},
+	}}
+	res.Includes.Media = []V2Media{{MediaKey: "3_1", Type: "photo", URL: "https://pbs.twimg.com/1.jpg"}}
+
+	var buf bytes.Buffer
+	if err := writeJSONTweets(&buf, res); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got jsonTweet
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("cannot decode %q: %v", buf.String(), err)
+	}
+	if got.Version != tweetJSONVersion || got.Text != "look & see" || got.URL != "https://x.com/alice/status/2" {
+		t.Errorf("got %+v", got)
+	}
+	if got.Author == nil || got.Author.Username != "alice" {
+		t.Errorf("author = %+v, want alice", got.Author)
+	}
+	if len(got.ReferencedTweets) != 2 {
+		t.Fatalf("got %d referenced tweets, want 2", len(got.ReferencedTweets))
+	}
+	if r := got.ReferencedTweets[0]; r.Author == nil || r.Author.Username != "bob" || r.URL != "https://x.com/bob/status/9" {
+		t.Errorf("quoted tweet = %+v", r)
+	}
+	if e := got.ReferencedTweets[0].Entities; e == nil || len(e.Hashtags) != 1 || e.Hashtags[0].Tag != "quoted" {
+		t.Errorf("quoted tweet entities = %+v", e)
+	}
+	if r := got.ReferencedTweets[1]; r.Author != nil || r.URL != "https://x.com/i/web/status/8" {
+		t.Errorf("missing replied_to tweet = %+v", r)
+	}
+	if len(got.Media) != 1 || got.Media[0].Type != "photo" {
+		t.Errorf("media = %+v", got.Media)
+	}
+}
+
+func TestWriteJSONTweetsNoteTweet(t *testing.T) {
+	res := V2TweetsResponse{Data: []V2Tweet{{
+		ID:        "1",
+		Text:      "short…",
+		Entities:  &V2Entities{Hashtags: []V2TagEntity{{Tag: "short"}}},
+		NoteTweet: &V2NoteTweet{Text: "long &amp; full", Entities: &V2Entities{Hashtags: []V2TagEntity{{Tag: "long"}}}},
+	}}}
+	var buf bytes.Buffer
+	if err := writeJSONTweets(&buf, res); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got jsonTweet
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("cannot decode %q: %v", buf.String(), err)
+	}
+	if got.Text != "long & full" {
+		t.Errorf("text = %q, want %q", got.Text, "long & full")
+	}
+	if got.Entities == nil || len(got.Entities.Hashtags) != 1 || got.Entities.Hashtags[0].Tag != "long" {
+		t.Errorf("entities = %+v, want those of the note tweet", got.Entities)
+	}
+}
+
+func TestOutputFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		app  App
+		want string
+	}{
+		{"search", App{search: "golang"}, "json,csv,tsv,ndjson,markdown,html"},
+		{"home", App{}, "json,csv,tsv,ndjson,markdown,html"},
+		{"counts", App{counts: true, search: "golang"}, "csv,json"},
+		{"muted", App{muted: true}, "csv,json"},
+		{"list members", App{list: "1", listMembers: true}, "csv,json"},
+		{"thread", App{thread: "1"}, ""},
+		{"dm", App{dm: true}, ""},
+		{"spaces", App{spaces: "golang"}, ""},
+		{"scheduler", App{scheduler: true}, ""},
+		{"queue", App{queue: true}, ""},
+		{"schedule", App{at: "+1h"}, ""},
+		{"queue edit", App{queueEdit: "1"}, ""},
+		{"stream", App{delay: time.Minute}, "json,csv,tsv,ndjson,markdown,html"},
+		{"retweet", App{inreply: "1"}, ""},
+	}
+	for _, tt := range tests {
+		_, formats := tt.app.command()
+		if got := strings.Join(formats, ","); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseUserListPlain(t *testing.T) {
+	in := "alice: Alice\nbob: Bob Smith\n"
+	got, err := parseUserList(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"@alice", "@bob"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseUserListVerbose(t *testing.T) {
+	in := "alice: Alice\n  12345\n\nbob: Bob\n  67890\n\n"
+	got, err := parseUserList(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"@alice", "@bob"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseUserListJSON(t *testing.T) {
+	in := `{"id":"1","name":"Alice","username":"alice"}` + "\n" + `{"id":"2"}` + "\n"
+	got, err := parseUserList(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"@alice", "id:2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseUserListHandWritten(t *testing.T) {
+	in := "# spam accounts\n@carol\nid:12345\n1234\n1234567890123456789\ndave\n"
+	got, err := parseUserList(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"@carol", "id:12345", "@1234", "id:1234567890123456789", "@dave"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseUserListBadJSON(t *testing.T) {
+	if _, err := parseUserList(strings.NewReader("{nope\n")); err == nil {
+		t.Errorf("expected error for malformed JSON line")
+	}
+}
+
+func TestParseUserListCSV(t *testing.T) {
+	in := "id,username,name\n1,alice,\"Alice, Jr.\"\n2,bob,Bob\n"
+	got, err := parseUserList(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"@alice", "@bob"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseUserListCommaInName(t *testing.T) {
+	in := "alice: Smith, John\nbob: Bob\n"
+	got, err := parseUserList(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"@alice", "@bob"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseUserListCSVWithoutHeader(t *testing.T) {
+	in := "1,alice,\"Smith, John\"\n"
+	got, err := parseUserList(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"@alice"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestParseUserRef(t *testing.T) {
+	tests := []struct {
+		in           string
+		id, username string
+	}{
+		{"alice", "", "alice"},
+		{"@alice", "", "alice"},
+		{"1234", "", "1234"},
+		{"@1234", "", "1234"},
+		{"123456789012345", "", "123456789012345"},
+		{"1234567890123456", "1234567890123456", ""},
+		{"id:783214", "783214", ""},
+	}
+	for _, tt := range tests {
+		id, username := parseUserRef(tt.in)
+		if id != tt.id || username != tt.username {
+			t.Errorf("parseUserRef(%q) = %q, %q, want %q, %q", tt.in, id, username, tt.id, tt.username)
+		}
+	}
+}
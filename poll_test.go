@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePollOptions(t *testing.T) {
+	got := parsePollOptions("Option A| Option B |Option C")
+	want := []string{"Option A", "Option B", "Option C"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := parsePollOptions(""); got != nil {
+		t.Errorf("got %q, want nil", got)
+	}
+}
+
+func TestValidatePoll(t *testing.T) {
+	valid := tweetOptions{PollOptions: []string{"A", "B"}, PollDuration: 24 * time.Hour}
+	if err := validatePoll(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tests := map[string]tweetOptions{
+		"one option":    {PollOptions: []string{"A"}, PollDuration: time.Hour},
+		"five options":  {PollOptions: []string{"A", "B", "C", "D", "E"}, PollDuration: time.Hour},
+		"empty option":  {PollOptions: []string{"A", ""}, PollDuration: time.Hour},
+		"too long":      {PollOptions: []string{"A", strings.Repeat("x", 26)}, PollDuration: time.Hour},
+		"too short":     {PollOptions: []string{"A", "B"}, PollDuration: 4 * time.Minute},
+		"too long poll": {PollOptions: []string{"A", "B"}, PollDuration: 8 * 24 * time.Hour},
+		"fractional":    {PollOptions: []string{"A", "B"}, PollDuration: 90 * time.Second},
+		"with media":    {PollOptions: []string{"A", "B"}, PollDuration: time.Hour, MediaIDs: []string{"1"}},
+		"with quote":    {PollOptions: []string{"A", "B"}, PollDuration: time.Hour, QuoteTweetID: "1"},
+	}
+	for name, opts := range tests {
+		if err := validatePoll(opts); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestValidatePollCountsRunes(t *testing.T) {
+	opts := tweetOptions{PollOptions: []string{"A", strings.Repeat("あ", 25)}, PollDuration: time.Hour}
+	if err := validatePoll(opts); err != nil {
+		t.Errorf("unexpected error for 25 wide characters: %v", err)
+	}
+}
+
+func TestFormatPoll(t *testing.T) {
+	poll := V2Poll{
+		Options: []V2PollOption{
+			{Position: 1, Label: "Yes", Votes: 3},
+			{Position: 2, Label: "No", Votes: 1},
+		},
+		VotingStatus: "closed",
+	}
+	want := "[1] Yes: 3 (75%)\n[2] No: 1 (25%)\n4 votes, closed"
+	if got := formatPoll(poll); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const (
+	schedulePending  = "pending"
+	schedulePosting  = "posting"
+	schedulePosted   = "posted"
+	scheduleFailed   = "failed"
+	scheduleCanceled = "canceled"
+	scheduleSkipped  = "skipped"
+
+	// missedAfter is how late an item has to be to count as missed, for
+	// example because the scheduler was not running when it came due.
+	missedAfter = 5 * time.Minute
+
+	defaultSchedulerInterval = 30 * time.Second
+	scheduleTimeLayout       = "2006-01-02 15:04"
+
+	// queueLockTimeout is how long to wait for another process to release
+	// the queue. A lock older than staleLockAge was left behind by a process
+	// that died while holding it.
+	queueLockTimeout = 10 * time.Second
+	staleLockAge     = time.Minute
+
+	// staleClaimAge is how long a post can stay claimed by a scheduler.
+	// Older claims were left by a scheduler that died while posting.
+	staleClaimAge = 30 * time.Minute
+)
+
+// scheduledPost is a tweet, or a thread when it has several parts, waiting
+// in the queue. Media are stored as file paths and uploaded when posting.
+type scheduledPost struct {
+	ID            int          `json:"id"`
+	At            time.Time    `json:"at"`
+	Parts         []threadPart `json:"parts"`
+	InReplyTo     string       `json:"in_reply_to,omitempty"`
+	ReplySettings string       `json:"reply_settings,omitempty"`
+	Status        string       `json:"status"`
+	ClaimedBy     string       `json:"claimed_by,omitempty"`
+	ClaimedAt     *time.Time   `json:"claimed_at,omitempty"`
+	TweetIDs      []string     `json:"tweet_ids,omitempty"`
+	Error         string       `json:"error,omitempty"`
+	PostedAt      *time.Time   `json:"posted_at,omitempty"`
+}
+
+type postQueue struct {
+	NextID int             `json:"next_id"`
+	Posts  []scheduledPost `json:"posts"`
+}
+
+func (q *postQueue) add(p scheduledPost) int {
+	if q.NextID == 0 {
+		q.NextID = 1
+	}
+	p.ID = q.NextID
+	p.Status = schedulePending
+	q.NextID++
+	q.Posts = append(q.Posts, p)
+	return p.ID
+}
+
+func (q *postQueue) find(id int) *scheduledPost {
+	for i := range q.Posts {
+		if q.Posts[i].ID == id {
+			return &q.Posts[i]
+		}
+	}
+	return nil
+}
+
+// due returns the IDs of pending posts whose time has come, oldest first.
+// Posts more than missedAfter late are marked skipped instead when the
+// policy is "skip". Posts claimed by a scheduler that died while posting
+// are marked failed, since some of their tweets may have been posted.
+func (q *postQueue) due(now time.Time, missed string) []int {
+	var ids []int
+	for i := range q.Posts {
+		p := &q.Posts[i]
+		if p.Status == schedulePosting && p.ClaimedAt != nil && now.Sub(*p.ClaimedAt) > staleClaimAge {
+			p.Status = scheduleFailed
+			p.Error = "interrupted while posting by " + p.ClaimedBy
+			p.ClaimedBy, p.ClaimedAt = "", nil
+			continue
+		}
+		if p.Status != schedulePending || p.At.After(now) {
+			continue
+		}
+		if missed == "skip" && now.Sub(p.At) > missedAfter && len(p.TweetIDs) == 0 {
+			p.Status = scheduleSkipped
+			p.Error = fmt.Sprintf("missed by %v", now.Sub(p.At).Round(time.Second))
+			continue
+		}
+		ids = append(ids, p.ID)
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		return q.find(ids[i]).At.Before(q.find(ids[j]).At)
+	})
+	return ids
+}
+
+func (app *App) queueFile() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	if app.profile == "" {
+		return filepath.Join(dir, "queue.json"), nil
+	}
+	return filepath.Join(dir, "queue-"+app.profile+".json"), nil
+}
+
+func loadQueue(file string) (postQueue, error) {
+	var q postQueue
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return q, err
+	}
+	err = json.Unmarshal(b, &q)
+	return q, err
+}
+
+// saveQueue writes the queue through a temporary file, so the scheduler
+// never reads a half-written queue.
+func saveQueue(file string, q postQueue) error {
+	b, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// lockQueue takes the lock of the queue file and returns the function that
+// releases it. The lock is a file created next to the queue, which works on
+// every platform.
+func lockQueue(file string) (func(), error) {
+	lock := file + ".lock"
+	deadline := time.Now().Add(queueLockTimeout)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if fi, err := os.Stat(lock); err == nil && time.Since(fi.ModTime()) > staleLockAge {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("queue is locked by another process: remove %s if none is running", lock)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// updateQueue loads the queue, applies fn and saves it again. The queue is
+// locked meanwhile, so the scheduler and a command editing the queue do not
+// overwrite each other's changes.
+func updateQueue(file string, fn func(q *postQueue) error) error {
+	unlock, err := lockQueue(file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	q, err := loadQueue(file)
+	if err != nil {
+		return err
+	}
+	if err := fn(&q); err != nil {
+		return err
+	}
+	return saveQueue(file, q)
+}
+
+// parseScheduleTime parses the time given with -at: RFC 3339,
+// "2006-01-02 15:04", "15:04" for the next time of day, or "+30m" for a
+// duration from now.
+func parseScheduleTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "+") {
+		d, err := time.ParseDuration(s[1:])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid schedule time %q: %v", s, err)
+		}
+		return now.Add(d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{scheduleTimeLayout, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid schedule time %q: use RFC 3339, \"YYYY-MM-DD HH:MM\", \"HH:MM\" or \"+DURATION\"", s)
+}
+
+// scheduledParts builds the content of a queued post from the text
+// arguments, -ff or -ft, and -m. Media paths are made absolute since the
+// scheduler may run in another directory.
+func (app *App) scheduledParts() ([]threadPart, error) {
+	var parts []threadPart
+	switch {
+	case app.threadFile != "":
+		content, err := readFile(app.threadFile)
+		if err != nil {
+			return nil, err
+		}
+		parts, err = parseThreadParts(string(content))
+		if err != nil {
+			return nil, err
+		}
+		if app.threadFile != "-" {
+			resolveMedia(parts, filepath.Dir(app.threadFile))
+		}
+	case app.fromfile != "":
+		text, err := readFile(app.fromfile)
+		if err != nil {
+			return nil, err
+		}
+		parts = []threadPart{{Text: strings.TrimRight(string(text), "\r\n")}}
+	case flag.NArg() > 0:
+		parts = []threadPart{{Text: strings.Join(flag.Args(), " ")}}
+	}
+	if len(app.media) > 0 {
+		if len(parts) == 0 {
+			parts = []threadPart{{}}
+		}
+		parts[0].Media = append(append([]string{}, app.media...), parts[0].Media...)
+		if err := checkThreadMedia(parts, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range parts {
+		for j, file := range parts[i].Media {
+			abs, err := filepath.Abs(file)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := os.Stat(abs); err != nil {
+				return nil, err
+			}
+			parts[i].Media[j] = abs
+		}
+	}
+	return parts, nil
+}
+
+// replaceParts changes the content of p to parts. When only media were
+// given, they replace the media of the first tweet and the queued text is
+// kept.
+func (p *scheduledPost) replaceParts(parts []threadPart, mediaOnly bool) {
+	switch {
+	case mediaOnly && len(p.Parts) > 0:
+		p.Parts[0].Media = parts[0].Media
+	case len(parts) > 0:
+		p.Parts = parts
+	}
+}
+
+func (app *App) checkSchedulable() {
+	if app.quote != "" || app.poll != "" || app.edit != "" || app.dmTo != "" {
+		log.Fatal("cannot schedule quote tweets, polls, edits or direct messages")
+	}
+}
+
+func (app *App) schedulePost() {
+	app.checkSchedulable()
+	at, err := parseScheduleTime(app.at, time.Now())
+	if err != nil {
+		log.Fatal(err)
+	}
+	parts, err := app.scheduledParts()
+	if err != nil {
+		log.Fatalf("cannot read post to schedule: %v", err)
+	}
+	if len(parts) == 0 {
+		log.Fatal("nothing to schedule")
+	}
+
+	file, err := app.queueFile()
+	if err != nil {
+		log.Fatalf("cannot locate queue: %v", err)
+	}
+	var id int
+	err = updateQueue(file, func(q *postQueue) error {
+		id = q.add(scheduledPost{At: at, Parts: parts, InReplyTo: app.inreply, ReplySettings: app.replySettings})
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("cannot save queue: %v", err)
+	}
+	fmt.Printf("scheduled: %d at %s\n", id, at.Local().Format(scheduleTimeLayout))
+}
+
+func (app *App) editQueued() {
+	app.checkSchedulable()
+	id, err := strconv.Atoi(app.queueEdit)
+	if err != nil {
+		log.Fatalf("invalid queue ID: %v", app.queueEdit)
+	}
+	parts, err := app.scheduledParts()
+	if err != nil {
+		log.Fatalf("cannot read post to schedule: %v", err)
+	}
+	mediaOnly := len(app.media) > 0 && app.threadFile == "" && app.fromfile == "" && flag.NArg() == 0
+	var at time.Time
+	if app.at != "" {
+		at, err = parseScheduleTime(app.at, time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	file, err := app.queueFile()
+	if err != nil {
+		log.Fatalf("cannot locate queue: %v", err)
+	}
+	err = updateQueue(file, func(q *postQueue) error {
+		p := q.find(id)
+		if p == nil {
+			return fmt.Errorf("no queued post %d", id)
+		}
+		if p.Status != schedulePending && p.Status != scheduleFailed {
+			return fmt.Errorf("post %d is already %s", id, p.Status)
+		}
+		if !at.IsZero() {
+			p.At = at
+		}
+		p.replaceParts(parts, mediaOnly)
+		if isFlagSet("i") {
+			p.InReplyTo = app.inreply
+		}
+		if isFlagSet("reply-settings") {
+			p.ReplySettings = app.replySettings
+		}
+		// A failed post goes back to the queue. Parts that were already
+		// posted are not posted again.
+		p.Status = schedulePending
+		p.Error = ""
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("cannot edit queued post: %v", err)
+	}
+	fmt.Println("edited:", id)
+}
+
+func (app *App) cancelQueued() {
+	id, err := strconv.Atoi(app.queueCancel)
+	if err != nil {
+		log.Fatalf("invalid queue ID: %v", app.queueCancel)
+	}
+	file, err := app.queueFile()
+	if err != nil {
+		log.Fatalf("cannot locate queue: %v", err)
+	}
+	err = updateQueue(file, func(q *postQueue) error {
+		p := q.find(id)
+		if p == nil {
+			return fmt.Errorf("no queued post %d", id)
+		}
+		if p.Status != schedulePending && p.Status != scheduleFailed {
+			return fmt.Errorf("post %d is already %s", id, p.Status)
+		}
+		p.Status = scheduleCanceled
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("cannot cancel queued post: %v", err)
+	}
+	fmt.Println("canceled:", id)
+}
+
+func showQueue(posts []scheduledPost, asjson bool, verbose bool) {
+	if asjson {
+		for _, p := range posts {
+			json.NewEncoder(os.Stdout).Encode(p)
+		}
+		return
+	}
+	for _, p := range posts {
+		text := ""
+		if len(p.Parts) > 0 {
+			text = p.Parts[0].Text
+		}
+		if !verbose {
+			text = strings.SplitN(text, "\n", 2)[0]
+		}
+		color.Set(color.FgHiRed)
+		fmt.Printf("%d", p.ID)
+		color.Set(color.Reset)
+		fmt.Printf(" %s %s: %s\n", p.At.Local().Format(scheduleTimeLayout), p.Status, text)
+		if len(p.Parts) > 1 {
+			fmt.Printf("  thread of %d parts\n", len(p.Parts))
+		}
+		if len(p.TweetIDs) > 0 {
+			fmt.Println("  tweets:", strings.Join(p.TweetIDs, " "))
+		}
+		if p.Error != "" {
+			fmt.Println("  error:", p.Error)
+		}
+		if verbose {
+			if p.InReplyTo != "" {
+				fmt.Println("  in reply to:", p.InReplyTo)
+			}
+			if p.ReplySettings != "" {
+				fmt.Println("  reply settings:", p.ReplySettings)
+			}
+			for i, part := range p.Parts {
+				if i > 0 {
+					fmt.Printf("  part %d: %s\n", i+1, part.Text)
+				}
+				for _, m := range part.Media {
+					fmt.Printf("  media %d: %s\n", i+1, m)
+				}
+			}
+		}
+	}
+}
+
+func (app *App) showQueue() {
+	file, err := app.queueFile()
+	if err != nil {
+		log.Fatalf("cannot locate queue: %v", err)
+	}
+	q, err := loadQueue(file)
+	if err != nil {
+		log.Fatalf("cannot load queue: %v", err)
+	}
+	showQueue(q.Posts, app.asjson, app.verbose)
+}
+
+// postScheduled posts p, resuming a thread after the parts posted before.
+// The queue is saved after every part, so nothing is posted twice when the
+// scheduler is restarted.
+func (app *App) postScheduled(file string, p scheduledPost) ([]string, error) {
+	first := tweetOptions{InReplyTo: p.InReplyTo, ReplySettings: p.ReplySettings}
+	return app.postParts(p.Parts, first, p.TweetIDs, func(posted []string) {
+		err := updateQueue(file, func(q *postQueue) error {
+			if qp := q.find(p.ID); qp != nil {
+				qp.TweetIDs = posted
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("cannot save queue: %v", err)
+		}
+	})
+}
+
+// schedulerOwner identifies this process in the claims of the queue.
+func schedulerOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// runDue posts every due post in the queue file with post and records the
+// result. Each post is claimed before posting, so schedulers running at the
+// same time never post it twice.
+func runDue(file string, now time.Time, missed string, post func(scheduledPost) ([]string, error)) error {
+	var due []int
+	err := updateQueue(file, func(q *postQueue) error {
+		due = q.due(now, missed)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	owner := schedulerOwner()
+	for _, id := range due {
+		var claimed *scheduledPost
+		err := updateQueue(file, func(q *postQueue) error {
+			p := q.find(id)
+			// The post may have been edited, canceled or claimed by another
+			// scheduler in the meantime.
+			if p == nil || p.Status != schedulePending || p.At.After(now) {
+				return nil
+			}
+			claimedAt := time.Now()
+			p.Status = schedulePosting
+			p.ClaimedBy = owner
+			p.ClaimedAt = &claimedAt
+			c := *p
+			claimed = &c
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if claimed == nil {
+			continue
+		}
+		ids, perr := post(*claimed)
+		err = updateQueue(file, func(q *postQueue) error {
+			p := q.find(id)
+			if p == nil {
+				return nil
+			}
+			p.ClaimedBy, p.ClaimedAt = "", nil
+			p.TweetIDs = ids
+			if perr != nil {
+				p.Status = scheduleFailed
+				p.Error = perr.Error()
+				return nil
+			}
+			posted := time.Now()
+			p.Status = schedulePosted
+			p.Error = ""
+			p.PostedAt = &posted
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if perr != nil {
+			log.Printf("cannot post scheduled %d: %v", id, perr)
+		} else {
+			fmt.Printf("tweeted: %d %s\n", id, strings.Join(ids, " "))
+		}
+	}
+	return nil
+}
+
+func (app *App) runScheduler() {
+	if app.missed != "post" && app.missed != "skip" {
+		log.Fatalf("invalid -missed policy %q: use post or skip", app.missed)
+	}
+	file, err := app.queueFile()
+	if err != nil {
+		log.Fatalf("cannot locate queue: %v", err)
+	}
+	interval := app.delay
+	if interval <= 0 {
+		interval = defaultSchedulerInterval
+	}
+
+	post := func(p scheduledPost) ([]string, error) {
+		return app.postScheduled(file, p)
+	}
+	for {
+		if err := runDue(file, time.Now(), app.missed, post); err != nil {
+			log.Printf("cannot update queue: %v", err)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseScheduleTime(t *testing.T) {
+	loc := time.FixedZone("JST", 9*60*60)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, loc)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-10-20 09:30", time.Date(2026, 10, 20, 9, 30, 0, 0, loc)},
+		{"2026-10-20T09:30", time.Date(2026, 10, 20, 9, 30, 0, 0, loc)},
+		{"2026-10-20T09:30:00Z", time.Date(2026, 10, 20, 9, 30, 0, 0, time.UTC)},
+		{"18:00", time.Date(2026, 10, 18, 18, 0, 0, 0, loc)},
+		{"08:00", time.Date(2026, 10, 19, 8, 0, 0, 0, loc)},
+		{"12:00", time.Date(2026, 10, 19, 12, 0, 0, 0, loc)},
+		{"+90m", now.Add(90 * time.Minute)},
+	}
+	for _, tt := range tests {
+		got, err := parseScheduleTime(tt.in, now)
+		if err != nil {
+			t.Errorf("parseScheduleTime(%q): unexpected error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseScheduleTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "tomorrow", "+soon", "25:00"} {
+		if _, err := parseScheduleTime(in, now); err == nil {
+			t.Errorf("parseScheduleTime(%q): expected error", in)
+		}
+	}
+}
+
+func TestQueueDue(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	var q postQueue
+	q.add(scheduledPost{At: now.Add(-time.Minute)})
+	q.add(scheduledPost{At: now.Add(-time.Hour)})
+	q.add(scheduledPost{At: now.Add(time.Minute)})
+	canceled := q.add(scheduledPost{At: now.Add(-time.Minute)})
+	q.find(canceled).Status = scheduleCanceled
+
+	if got := q.due(now, "post"); !reflect.DeepEqual(got, []int{2, 1}) {
+		t.Errorf("due with post policy = %v, want [2 1]", got)
+	}
+	if got := q.due(now, "skip"); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("due with skip policy = %v, want [1]", got)
+	}
+	if got := q.find(2).Status; got != scheduleSkipped {
+		t.Errorf("missed post status = %q, want %q", got, scheduleSkipped)
+	}
+}
+
+func TestRunDueRecordsResults(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "queue.json")
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	var q postQueue
+	q.add(scheduledPost{At: now, Parts: []threadPart{{Text: "ok"}, {Text: "ok 2"}}})
+	q.add(scheduledPost{At: now, Parts: []threadPart{{Text: "fail"}}})
+	q.add(scheduledPost{At: now.Add(time.Hour), Parts: []threadPart{{Text: "later"}}})
+	if err := saveQueue(file, q); err != nil {
+		t.Fatal(err)
+	}
+
+	var posted []string
+	post := func(p scheduledPost) ([]string, error) {
+		posted = append(posted, p.Parts[0].Text)
+		if p.Parts[0].Text == "fail" {
+			return nil, errors.New("403 Forbidden")
+		}
+		return []string{"100", "101"}, nil
+	}
+	if err := runDue(file, now, "post", post); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(posted, []string{"ok", "fail"}) {
+		t.Errorf("posted %v, want [ok fail]", posted)
+	}
+
+	q, err := loadQueue(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := q.find(1); p.Status != schedulePosted || !reflect.DeepEqual(p.TweetIDs, []string{"100", "101"}) || p.PostedAt == nil {
+		t.Errorf("post 1 = %+v, want posted with IDs", p)
+	}
+	if p := q.find(2); p.Status != scheduleFailed || p.Error != "403 Forbidden" {
+		t.Errorf("post 2 = %+v, want failed with error", p)
+	}
+	if p := q.find(3); p.Status != schedulePending {
+		t.Errorf("post 3 status = %q, want pending", p.Status)
+	}
+
+	posted = nil
+	if err := runDue(file, now, "post", post); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(posted) != 0 {
+		t.Errorf("posted %v again", posted)
+	}
+}
+
+func TestRunDueConcurrent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "queue.json")
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	var q postQueue
+	for range 5 {
+		q.add(scheduledPost{At: now, Parts: []threadPart{{Text: "x"}}})
+	}
+	if err := saveQueue(file, q); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	posted := make(map[int]int)
+	post := func(p scheduledPost) ([]string, error) {
+		if p.Status != schedulePosting || p.ClaimedBy == "" {
+			t.Errorf("post %d was not claimed: %+v", p.ID, p)
+		}
+		mu.Lock()
+		posted[p.ID]++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		return []string{"100"}, nil
+	}
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := runDue(file, now, "post", post); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for id := 1; id <= 5; id++ {
+		if posted[id] != 1 {
+			t.Errorf("post %d was posted %d times, want once", id, posted[id])
+		}
+	}
+	q, err := loadQueue(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range q.Posts {
+		if p.Status != schedulePosted || p.ClaimedBy != "" || p.ClaimedAt != nil {
+			t.Errorf("post %d = %+v, want posted without a claim", p.ID, p)
+		}
+	}
+}
+
+func TestQueueDueStaleClaim(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	recent, stale := now.Add(-time.Minute), now.Add(-time.Hour)
+	var q postQueue
+	q.add(scheduledPost{At: now.Add(-time.Hour)})
+	q.add(scheduledPost{At: now.Add(-time.Hour)})
+	q.Posts[0].Status, q.Posts[0].ClaimedBy, q.Posts[0].ClaimedAt = schedulePosting, "host:1", &recent
+	q.Posts[1].Status, q.Posts[1].ClaimedBy, q.Posts[1].ClaimedAt = schedulePosting, "host:2", &stale
+
+	if got := q.due(now, "post"); len(got) != 0 {
+		t.Errorf("due = %v, want claimed posts skipped", got)
+	}
+	if p := q.find(1); p.Status != schedulePosting {
+		t.Errorf("recently claimed post status = %q, want %q", p.Status, schedulePosting)
+	}
+	if p := q.find(2); p.Status != scheduleFailed || p.ClaimedAt != nil {
+		t.Errorf("stale claim = %+v, want failed", p)
+	}
+}
+
+func TestReplaceParts(t *testing.T) {
+	p := scheduledPost{Parts: []threadPart{{Text: "first", Media: []string{"/old.png"}}, {Text: "second"}}}
+	p.replaceParts([]threadPart{{Media: []string{"/new.png"}}}, true)
+	want := []threadPart{{Text: "first", Media: []string{"/new.png"}}, {Text: "second"}}
+	if !reflect.DeepEqual(p.Parts, want) {
+		t.Errorf("media only edit = %+v, want %+v", p.Parts, want)
+	}
+
+	p.replaceParts([]threadPart{{Text: "new", Media: []string{"/new.png"}}}, false)
+	want = []threadPart{{Text: "new", Media: []string{"/new.png"}}}
+	if !reflect.DeepEqual(p.Parts, want) {
+		t.Errorf("text edit = %+v, want %+v", p.Parts, want)
+	}
+
+	p.replaceParts(nil, false)
+	if !reflect.DeepEqual(p.Parts, want) {
+		t.Errorf("edit without content = %+v, want %+v", p.Parts, want)
+	}
+}
+
+func TestUpdateQueueConcurrent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "queue.json")
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := updateQueue(file, func(q *postQueue) error {
+				q.add(scheduledPost{Parts: []threadPart{{Text: "x"}}})
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	q, err := loadQueue(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Posts) != 20 {
+		t.Errorf("got %d posts, want 20: updates were lost", len(q.Posts))
+	}
+	if _, err := os.Stat(file + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestLockQueueStale(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "queue.json")
+	if err := os.WriteFile(file+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(file+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := lockQueue(file)
+	if err != nil {
+		t.Fatalf("stale lock not taken over: %v", err)
+	}
+	unlock()
+}
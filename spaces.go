@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type V2Space struct {
+	ID               string   `json:"id"`
+	State            string   `json:"state"`
+	Title            string   `json:"title,omitempty"`
+	CreatorID        string   `json:"creator_id,omitempty"`
+	HostIDs          []string `json:"host_ids,omitempty"`
+	SpeakerIDs       []string `json:"speaker_ids,omitempty"`
+	Lang             string   `json:"lang,omitempty"`
+	ParticipantCount int      `json:"participant_count,omitempty"`
+	SubscriberCount  int      `json:"subscriber_count,omitempty"`
+	ScheduledStart   string   `json:"scheduled_start,omitempty"`
+	StartedAt        string   `json:"started_at,omitempty"`
+	EndedAt          string   `json:"ended_at,omitempty"`
+	IsTicketed       bool     `json:"is_ticketed,omitempty"`
+}
+
+type V2SpacesResponse struct {
+	Data     []V2Space  `json:"data"`
+	Includes V2Includes `json:"includes"`
+	Meta     V2Meta     `json:"meta"`
+	Errors   []V2Error  `json:"errors,omitempty"`
+}
+
+type V2SpaceResponse struct {
+	Data     V2Space    `json:"data"`
+	Includes V2Includes `json:"includes"`
+}
+
+func v2SpaceFields() map[string]string {
+	return map[string]string{
+		"space.fields": "title,state,creator_id,host_ids,speaker_ids,lang,participant_count,subscriber_count,scheduled_start,started_at,ended_at,is_ticketed",
+		"user.fields":  "name,username,profile_image_url",
+		"expansions":   "creator_id,host_ids,speaker_ids",
+	}
+}
+
+func (app *App) searchSpaces(query, state, count string) (V2SpacesResponse, error) {
+	switch state {
+	case "":
+		state = "all"
+	case "live", "scheduled", "all":
+	default:
+		return V2SpacesResponse{}, fmt.Errorf("invalid space state: %s (live, scheduled or all)", state)
+	}
+
+	params := v2SpaceFields()
+	params["query"] = query
+	params["state"] = state
+	if count != "" {
+		params["max_results"] = count
+	}
+
+	var res V2SpacesResponse
+	err := app.callGet("https://api.twitter.com/2/spaces/search", params, &res)
+	return res, err
+}
+
+func (app *App) fetchSpace(spaceID string) (V2SpacesResponse, error) {
+	var res V2SpaceResponse
+	err := app.callGet("https://api.twitter.com/2/spaces/"+spaceID, v2SpaceFields(), &res)
+	if err != nil {
+		return V2SpacesResponse{}, err
+	}
+	return V2SpacesResponse{Data: []V2Space{res.Data}, Includes: res.Includes}, nil
+}
+
+func (app *App) fetchUserSpaces(user string) (V2SpacesResponse, error) {
+	userID, err := app.resolveUserID(user)
+	if err != nil {
+		return V2SpacesResponse{}, err
+	}
+
+	params := v2SpaceFields()
+	params["user_ids"] = userID
+
+	var res V2SpacesResponse
+	err = app.callGet("https://api.twitter.com/2/spaces/by/creator_ids", params, &res)
+	return res, err
+}
+
+func spaceUsers(ids []string, userMap map[string]V2User) string {
+	var names []string
+	for _, id := range ids {
+		if u, ok := userMap[id]; ok {
+			names = append(names, "@"+u.Username)
+		} else {
+			names = append(names, id)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// jsonSpace is a space as written by -json, with its users resolved from
+// the expansions. Users the API did not return are left out.
+type jsonSpace struct {
+	V2Space
+	Creator  *V2User  `json:"creator,omitempty"`
+	Hosts    []V2User `json:"hosts,omitempty"`
+	Speakers []V2User `json:"speakers,omitempty"`
+}
+
+func resolveSpaceUsers(ids []string, userMap map[string]V2User) []V2User {
+	var users []V2User
+	for _, id := range ids {
+		if u, ok := userMap[id]; ok {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+func newJSONSpace(space V2Space, userMap map[string]V2User) jsonSpace {
+	s := jsonSpace{
+		V2Space:  space,
+		Hosts:    resolveSpaceUsers(space.HostIDs, userMap),
+		Speakers: resolveSpaceUsers(space.SpeakerIDs, userMap),
+	}
+	if u, ok := userMap[space.CreatorID]; ok {
+		s.Creator = &u
+	}
+	return s
+}
+
+// formatSpaceDetails describes the state, schedule and people of a space,
+// one fact per line.
+func formatSpaceDetails(space V2Space, userMap map[string]V2User) string {
+	state := space.State
+	if space.IsTicketed {
+		state += ", ticketed"
+	}
+	if space.Lang != "" {
+		state += ", " + space.Lang
+	}
+	lines := []string{state}
+	switch {
+	case space.EndedAt != "":
+		lines = append(lines, "Ended: "+space.EndedAt)
+	case space.StartedAt != "":
+		lines = append(lines, "Started: "+space.StartedAt)
+	case space.ScheduledStart != "":
+		lines = append(lines, "Scheduled: "+space.ScheduledStart)
+	}
+	if space.CreatorID != "" {
+		lines = append(lines, "Creator: "+spaceUsers([]string{space.CreatorID}, userMap))
+	}
+	if len(space.HostIDs) > 0 {
+		lines = append(lines, "Hosts: "+spaceUsers(space.HostIDs, userMap))
+	}
+	if len(space.SpeakerIDs) > 0 {
+		lines = append(lines, "Speakers: "+spaceUsers(space.SpeakerIDs, userMap))
+	}
+	if space.ParticipantCount > 0 {
+		lines = append(lines, fmt.Sprintf("Participants: %d", space.ParticipantCount))
+	}
+	if space.SubscriberCount > 0 {
+		lines = append(lines, fmt.Sprintf("Subscribers: %d", space.SubscriberCount))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatSpacesText(res V2SpacesResponse) string {
+	if len(res.Data) == 0 {
+		return "No spaces found."
+	}
+
+	userMap := make(map[string]V2User)
+	for _, u := range res.Includes.Users {
+		userMap[u.ID] = u
+	}
+
+	var sb strings.Builder
+	for _, space := range res.Data {
+		fmt.Fprintf(&sb, "%s [%s]:\n%s\n\n", space.Title, space.ID, formatSpaceDetails(space, userMap))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func showV2Spaces(res V2SpacesResponse, asjson bool, verbose bool) {
+	userMap := make(map[string]V2User)
+	for _, u := range res.Includes.Users {
+		userMap[u.ID] = u
+	}
+
+	if asjson {
+		for _, space := range res.Data {
+			json.NewEncoder(os.Stdout).Encode(newJSONSpace(space, userMap))
+			os.Stdout.Sync()
+		}
+	} else if verbose {
+		for _, space := range res.Data {
+			color.Set(color.FgHiRed)
+			fmt.Println(space.Title)
+			color.Set(color.Reset)
+			fmt.Println("  " + space.ID)
+			fmt.Println("  " + strings.ReplaceAll(formatSpaceDetails(space, userMap), "\n", "\n  "))
+			fmt.Println()
+		}
+	} else {
+		for _, space := range res.Data {
+			color.Set(color.FgHiRed)
+			fmt.Print(space.State)
+			color.Set(color.Reset)
+			fmt.Println(": " + space.Title + " [" + space.ID + "]")
+		}
+	}
+}
+
+func (app *App) showSpaces() {
+	var res V2SpacesResponse
+	var err error
+	switch {
+	case app.space != "":
+		res, err = app.fetchSpace(app.space)
+	case app.spacesBy != "":
+		res, err = app.fetchUserSpaces(app.spacesBy)
+	default:
+		res, err = app.searchSpaces(app.spaces, app.spaceState, app.count)
+	}
+	if err != nil {
+		log.Fatalf("cannot get spaces: %v", err)
+	}
+	showV2Spaces(res, app.asjson, app.verbose)
+}
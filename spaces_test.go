@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFormatSpaceDetailsScheduled(t *testing.T) {
+	space := V2Space{
+		ID:             "1",
+		State:          "scheduled",
+		Lang:           "ja",
+		ScheduledStart: "2026-10-20T12:00:00.000Z",
+		CreatorID:      "u1",
+		HostIDs:        []string{"u1", "u9"},
+	}
+	userMap := map[string]V2User{"u1": {ID: "u1", Username: "alice"}}
+	want := "scheduled, ja\nScheduled: 2026-10-20T12:00:00.000Z\nCreator: @alice\nHosts: @alice, u9"
+	if got := formatSpaceDetails(space, userMap); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatSpaceDetailsLive(t *testing.T) {
+	space := V2Space{
+		State:            "live",
+		IsTicketed:       true,
+		StartedAt:        "2026-10-18T10:00:00.000Z",
+		SpeakerIDs:       []string{"u1"},
+		ParticipantCount: 42,
+	}
+	userMap := map[string]V2User{"u1": {ID: "u1", Username: "alice"}}
+	want := "live, ticketed\nStarted: 2026-10-18T10:00:00.000Z\nSpeakers: @alice\nParticipants: 42"
+	if got := formatSpaceDetails(space, userMap); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewJSONSpace(t *testing.T) {
+	space := V2Space{ID: "1", State: "live", CreatorID: "u1", HostIDs: []string{"u1", "u9"}, SpeakerIDs: []string{"u2"}}
+	userMap := map[string]V2User{
+		"u1": {ID: "u1", Username: "alice"},
+		"u2": {ID: "u2", Username: "bob"},
+	}
+	b, err := json.Marshal(newJSONSpace(space, userMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		ID       string   `json:"id"`
+		HostIDs  []string `json:"host_ids"`
+		Creator  *V2User  `json:"creator"`
+		Hosts    []V2User `json:"hosts"`
+		Speakers []V2User `json:"speakers"`
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != "1" || len(got.HostIDs) != 2 {
+		t.Errorf("space fields missing: %s", b)
+	}
+	if got.Creator == nil || got.Creator.Username != "alice" {
+		t.Errorf("creator = %+v, want alice", got.Creator)
+	}
+	if len(got.Hosts) != 1 || got.Hosts[0].Username != "alice" {
+		t.Errorf("hosts = %+v, want only alice", got.Hosts)
+	}
+	if len(got.Speakers) != 1 || got.Speakers[0].Username != "bob" {
+		t.Errorf("speakers = %+v, want bob", got.Speakers)
+	}
+}
+
+func TestFormatSpacesTextEmpty(t *testing.T) {
+	if got := formatSpacesText(V2SpacesResponse{}); got != "No spaces found." {
+		t.Errorf("got %q, want %q", got, "No spaces found.")
+	}
+}
+
+func TestSearchSpacesInvalidState(t *testing.T) {
+	if _, err := testApp().searchSpaces("go", "ended", ""); err == nil {
+		t.Errorf("expected error for invalid state")
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// terminalColumns returns the width of the terminal on stdout, or 0 when
+// stdout is not a terminal.
+func terminalColumns() int {
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// maxThreadPages limits how many pages of search results are fetched for a
+// single conversation, so a viral tweet does not exhaust the rate limit.
+const maxThreadPages = 10
+
+type threadNode struct {
+	Tweet   V2Tweet       `json:"tweet"`
+	Author  *V2User       `json:"author,omitempty"`
+	Replies []*threadNode `json:"replies,omitempty"`
+}
+
+func (app *App) fetchThread(tweetID string) (V2TweetsResponse, string, error) {
+	tweetRes, err := app.fetchTweet(tweetID)
+	if err != nil {
+		return V2TweetsResponse{}, "", err
+	}
+	if tweetRes.Data.ID == "" {
+		return V2TweetsResponse{}, "", fmt.Errorf("tweet not found: %s", tweetID)
+	}
+	res := V2TweetsResponse{
+		Data:     []V2Tweet{tweetRes.Data},
+		Includes: tweetRes.Includes,
+	}
+
+	conversationID := tweetRes.Data.ConversationID
+	if conversationID == "" {
+		conversationID = tweetRes.Data.ID
+	}
+	rootID := conversationID
+	if rootID != tweetRes.Data.ID {
+		rootRes, err := app.fetchTweet(rootID)
+		if err != nil || rootRes.Data.ID == "" {
+			// The root may be deleted, protected or withheld. Show the
+			// thread from the requested tweet instead.
+			if err == nil {
+				err = fmt.Errorf("tweet not found")
+			}
+			log.Printf("cannot get root tweet %s: %v", rootID, err)
+			rootID = tweetRes.Data.ID
+		} else {
+			res.Data = append(res.Data, rootRes.Data)
+			res.Includes.add(rootRes.Includes)
+		}
+	}
+
+	params := v2TweetFields()
+	params["tweet.fields"] += ",conversation_id,in_reply_to_user_id"
+	params["query"] = "conversation_id:" + conversationID
+	params["max_results"] = "100"
+	for range maxThreadPages {
+		var page V2TweetsResponse
+		err := app.callGet("https://api.twitter.com/2/tweets/search/recent", params, &page)
+		if err != nil {
+			return V2TweetsResponse{}, "", err
+		}
+		res.Data = append(res.Data, page.Data...)
+		res.Includes.add(page.Includes)
+		if page.Meta.NextToken == "" {
+			break
+		}
+		params["next_token"] = page.Meta.NextToken
+	}
+	if rootID != conversationID {
+		res.Data = threadDescendants(res.Data, rootID)
+	}
+	return res, rootID, nil
+}
+
+// threadDescendants returns the tweet id and the tweets replying to it,
+// directly or through other replies in tweets.
+func threadDescendants(tweets []V2Tweet, id string) []V2Tweet {
+	parents := make(map[string]string)
+	for _, t := range tweets {
+		for _, ref := range t.ReferencedTweets {
+			if ref.Type == "replied_to" {
+				parents[t.ID] = ref.ID
+			}
+		}
+	}
+	var result []V2Tweet
+	for _, t := range tweets {
+		cur := t.ID
+		// the depth limit guards against reply loops in broken data
+		for range len(tweets) + 1 {
+			if cur == id {
+				result = append(result, t)
+				break
+			}
+			parent, ok := parents[cur]
+			if !ok {
+				break
+			}
+			cur = parent
+		}
+	}
+	return result
+}
+
+// buildThread arranges the tweets of a conversation into a reply tree rooted
+// at rootID. Replies whose parent was not fetched are attached to the root.
+func buildThread(res V2TweetsResponse, rootID string) *threadNode {
+	userMap := make(map[string]V2User)
+	for _, u := range res.Includes.Users {
+		userMap[u.ID] = u
+	}
+
+	nodes := make(map[string]*threadNode)
+	var order []string
+	for _, tweet := range res.Data {
+		if _, ok := nodes[tweet.ID]; ok {
+			continue
+		}
+		node := &threadNode{Tweet: tweet}
+		if u, ok := userMap[tweet.AuthorID]; ok {
+			node.Author = &u
+		}
+		nodes[tweet.ID] = node
+		order = append(order, tweet.ID)
+	}
+
+	root, ok := nodes[rootID]
+	if !ok {
+		root = &threadNode{Tweet: V2Tweet{ID: rootID}}
+	}
+	for _, id := range order {
+		node := nodes[id]
+		if node == root {
+			continue
+		}
+		parent := root
+		for _, ref := range node.Tweet.ReferencedTweets {
+			if ref.Type == "replied_to" {
+				if p, ok := nodes[ref.ID]; ok && p != node {
+					parent = p
+				}
+			}
+		}
+		parent.Replies = append(parent.Replies, node)
+	}
+
+	var sortReplies func(*threadNode)
+	sortReplies = func(n *threadNode) {
+		sort.Slice(n.Replies, func(i, j int) bool {
+			return tweetIDLess(n.Replies[i].Tweet.ID, n.Replies[j].Tweet.ID)
+		})
+		for _, r := range n.Replies {
+			sortReplies(r)
+		}
+	}
+	sortReplies(root)
+	return root
+}
+
+// tweetIDLess orders tweet IDs numerically, which is also chronological.
+func tweetIDLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+func showThreadTree(root *threadNode, tweetMap map[string]V2Tweet, userMap map[string]V2User, asjson bool, verbose bool) {
+	if asjson {
+		json.NewEncoder(os.Stdout).Encode(root)
+		os.Stdout.Sync()
+		return
+	}
+
+	now := time.Now()
+	width := terminalColumns()
+	var walk func(n *threadNode, depth int)
+	walk = func(n *threadNode, depth int) {
+		indent := strings.Repeat("  ", depth)
+		username := ""
+		if n.Author != nil {
+			username = n.Author.Username
+		}
+		text := html.UnescapeString(tweetText(n.Tweet, tweetMap, userMap))
+		text = renderText(text, tweetEntities(n.Tweet, tweetMap))
+		when := ""
+		if n.Tweet.CreatedAt != "" {
+			when = " (" + displayTime.compact(n.Tweet.CreatedAt, now) + ")"
+		}
+		if verbose {
+			text = wrapText(text, width, stringWidth(indent)+2, indent+"  ")
+		} else {
+			text = wrapText(text, width, stringWidth(indent+username+when+": "), indent+"  ")
+		}
+		if verbose {
+			color.Set(color.FgHiRed)
+			fmt.Print(indent + username)
+			color.Set(color.Reset)
+			if n.Author != nil {
+				fmt.Print(": " + n.Author.Name)
+			}
+			fmt.Println()
+			fmt.Println(indent + "  " + text)
+			for _, line := range formatMetrics(n.Tweet) {
+				fmt.Println(indent + "  " + line)
+			}
+			fmt.Println(indent + "  " + n.Tweet.ID)
+			fmt.Println(indent + "  " + displayTime.full(n.Tweet.CreatedAt))
+			fmt.Println()
+		} else {
+			color.Set(color.FgHiRed)
+			fmt.Print(indent + username)
+			color.Set(color.Reset)
+			fmt.Print(when + ": ")
+			fmt.Println(text)
+		}
+		for _, r := range n.Replies {
+			walk(r, depth+1)
+		}
+	}
+	walk(root, 0)
+}
+
+func formatThreadText(root *threadNode, tweetMap map[string]V2Tweet, userMap map[string]V2User) string {
+	now := time.Now()
+	var sb strings.Builder
+	var walk func(n *threadNode, depth int)
+	walk = func(n *threadNode, depth int) {
+		indent := strings.Repeat("  ", depth)
+		var user V2User
+		if n.Author != nil {
+			user = *n.Author
+		}
+		text := html.UnescapeString(tweetText(n.Tweet, tweetMap, userMap))
+		text = strings.ReplaceAll(text, "\n", "\n"+indent)
+		fmt.Fprintf(&sb, "%s@%s (%s) [%s]%s:\n%s%s\n\n", indent, user.Username, user.Name, n.Tweet.ID, mcpTweetTime(n.Tweet, now), indent, text)
+		for _, r := range n.Replies {
+			walk(r, depth+1)
+		}
+	}
+	walk(root, 0)
+	return strings.TrimSpace(sb.String())
+}
+
+func (app *App) showThread() {
+	tweetID, err := parseTweetID(app.thread)
+	if err != nil {
+		log.Fatal(err)
+	}
+	res, rootID, err := app.fetchThread(tweetID)
+	if err != nil {
+		log.Fatalf("cannot get thread: %v", err)
+	}
+	tweetMap := make(map[string]V2Tweet)
+	for _, t := range res.Includes.Tweets {
+		tweetMap[t.ID] = t
+	}
+	userMap := make(map[string]V2User)
+	for _, u := range res.Includes.Users {
+		userMap[u.ID] = u
+	}
+	showThreadTree(buildThread(res, rootID), tweetMap, userMap, app.asjson, app.verbose)
+}
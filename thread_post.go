@@ -0,0 +1,381 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/clipperhouse/uax29/v2/graphemes"
+)
+
+const (
+	maxTweetLength  = 280
+	urlLength       = 23
+	threadSeparator = "---"
+)
+
+type threadPart struct {
+	Text  string   `json:"text"`
+	Media []string `json:"media,omitempty"`
+}
+
+type threadState struct {
+	Posted []string `json:"posted"`
+}
+
+var (
+	urlPattern   = regexp.MustCompile(`^https?://\S+$`)
+	mediaPattern = regexp.MustCompile(`^!\[[^\]]*\]\(([^)]+)\)$`)
+)
+
+// runeWeight follows the twitter-text configuration: Latin-1 and common
+// punctuation count as one, everything else (CJK, emoji, ...) counts as two.
+func runeWeight(r rune) int {
+	switch {
+	case r <= 4351,
+		8192 <= r && r <= 8205,
+		8208 <= r && r <= 8223,
+		8242 <= r && r <= 8247:
+		return 1
+	}
+	return 2
+}
+
+// isEmojiSequence reports whether the grapheme cluster g is an emoji made
+// of several code points, like a ZWJ sequence, a flag, an emoji with a skin
+// tone or a keycap.
+func isEmojiSequence(g string) bool {
+	if utf8.RuneCountInString(g) < 2 {
+		return false
+	}
+	for _, r := range g {
+		switch {
+		case r == 0x200D, r == 0xFE0F, r == 0x20E3,
+			0x1F1E6 <= r && r <= 0x1F1FF,
+			0x1F3FB <= r && r <= 0x1F3FF,
+			0xE0020 <= r && r <= 0xE007F:
+			return true
+		}
+	}
+	return false
+}
+
+// clusterWeight returns the weight of the grapheme cluster g. X counts an
+// emoji as two however many code points it is made of.
+func clusterWeight(g string) int {
+	if isEmojiSequence(g) {
+		return 2
+	}
+	n := 0
+	for _, r := range g {
+		n += runeWeight(r)
+	}
+	return n
+}
+
+func wordLength(word string) int {
+	if urlPattern.MatchString(word) {
+		return urlLength
+	}
+	n := 0
+	g := graphemes.FromString(word)
+	for g.Next() {
+		n += clusterWeight(g.Value())
+	}
+	return n
+}
+
+// tweetLength returns the weighted length of text as counted by X, where
+// every URL counts as 23 characters.
+func tweetLength(text string) int {
+	n := 0
+	word := 0
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if word < i {
+				n += wordLength(text[word:i])
+			}
+			n += runeWeight(r)
+			word = i + len(string(r))
+		}
+	}
+	if word < len(text) {
+		n += wordLength(text[word:])
+	}
+	return n
+}
+
+// splitTweetText splits text into chunks within the weighted length limit,
+// breaking at whitespace where possible and inside long words otherwise.
+func splitTweetText(text string) []string {
+	var chunks []string
+	var cur strings.Builder
+	curLen := 0
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		cur.Reset()
+		curLen = 0
+	}
+
+	for _, word := range splitWords(text) {
+		n := tweetLength(word)
+		if curLen+n <= maxTweetLength {
+			cur.WriteString(word)
+			curLen += n
+			continue
+		}
+		if strings.TrimSpace(word) == "" {
+			flush()
+			continue
+		}
+		flush()
+		if n <= maxTweetLength {
+			cur.WriteString(word)
+			curLen = n
+			continue
+		}
+		g := graphemes.FromString(word)
+		for g.Next() {
+			w := clusterWeight(g.Value())
+			if curLen+w > maxTweetLength {
+				flush()
+			}
+			cur.WriteString(g.Value())
+			curLen += w
+		}
+	}
+	flush()
+	return chunks
+}
+
+// splitWords splits text into words, keeping the whitespace that follows
+// each word attached to it.
+func splitWords(text string) []string {
+	var words []string
+	start := 0
+	inSpace := false
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			inSpace = true
+		} else if inSpace {
+			words = append(words, text[start:i])
+			start = i
+			inSpace = false
+		}
+	}
+	if start < len(text) {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// parseThreadParts splits the content of a thread file into tweets. Parts
+// are separated by a line containing only "---", and a part longer than the
+// limit is split further. A line like "![alt](image.png)" attaches media to
+// the part it appears in.
+func parseThreadParts(content string) ([]threadPart, error) {
+	var parts []threadPart
+	var lines []string
+	var media []string
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(lines, "\n"))
+		chunks := splitTweetText(text)
+		if len(chunks) == 0 && len(media) > 0 {
+			chunks = []string{""}
+		}
+		for i, chunk := range chunks {
+			part := threadPart{Text: chunk}
+			if i == 0 {
+				part.Media = media
+			}
+			parts = append(parts, part)
+		}
+		lines = nil
+		media = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == threadSeparator {
+			flush()
+			continue
+		}
+		if m := mediaPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			media = append(media, m[1])
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+
+	if len(parts) == 0 {
+		return nil, errors.New("thread file is empty")
+	}
+	if err := checkThreadMedia(parts, 0); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// maxTweetMedia is the number of media a tweet can have.
+const maxTweetMedia = 4
+
+// checkThreadMedia returns an error when a part would have more media than
+// a tweet can take. extra media, given with -m, go to the first part.
+func checkThreadMedia(parts []threadPart, extra int) error {
+	for i, part := range parts {
+		n := len(part.Media)
+		if i == 0 {
+			n += extra
+		}
+		if n > maxTweetMedia {
+			return fmt.Errorf("part %d has more than %d media", i+1, maxTweetMedia)
+		}
+	}
+	return nil
+}
+
+func (app *App) threadStateFile(content []byte) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(app.profile + "\x00"))
+	h.Write(content)
+	return filepath.Join(dir, "thread-"+hex.EncodeToString(h.Sum(nil))[:16]+".json"), nil
+}
+
+func loadThreadState(file string) (threadState, error) {
+	var state threadState
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(b, &state)
+	return state, err
+}
+
+func saveThreadState(file string, state threadState) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, b, 0600)
+}
+
+// resolveMedia makes the media paths of parts relative to baseDir.
+func resolveMedia(parts []threadPart, baseDir string) {
+	for i := range parts {
+		for j, file := range parts[i].Media {
+			if !filepath.IsAbs(file) {
+				parts[i].Media[j] = filepath.Join(baseDir, file)
+			}
+		}
+	}
+}
+
+// postParts posts parts as a chain of replies, skipping the parts whose IDs
+// are already in posted. The first part replies to first.InReplyTo and gets
+// first.MediaIDs in addition to its own media; first.ReplySettings applies
+// to every part. onPosted is called with all IDs after every post, so the
+// progress can be saved for resuming.
+func (app *App) postParts(parts []threadPart, first tweetOptions, posted []string, onPosted func([]string)) ([]string, error) {
+	prev := first.InReplyTo
+	if len(posted) > 0 {
+		prev = posted[len(posted)-1]
+	} else if err := checkThreadMedia(parts, len(first.MediaIDs)); err != nil {
+		return posted, err
+	}
+
+	for i := len(posted); i < len(parts); i++ {
+		opts := tweetOptions{InReplyTo: prev, ReplySettings: first.ReplySettings}
+		if i == 0 {
+			opts.MediaIDs = append(opts.MediaIDs, first.MediaIDs...)
+		}
+		for _, file := range parts[i].Media {
+			id, err := app.upload(file)
+			if err != nil {
+				return posted, fmt.Errorf("cannot upload media for part %d/%d: %v", i+1, len(parts), err)
+			}
+			opts.MediaIDs = append(opts.MediaIDs, id)
+		}
+
+		id, err := app.createTweet(parts[i].Text, opts)
+		if err != nil {
+			return posted, fmt.Errorf("cannot post part %d/%d: %v", i+1, len(parts), err)
+		}
+		posted = append(posted, id)
+		if onPosted != nil {
+			onPosted(posted)
+		}
+		prev = id
+	}
+	return posted, nil
+}
+
+func (app *App) postThread() {
+	content, err := readFile(app.threadFile)
+	if err != nil {
+		log.Fatalf("cannot read a new thread: %v", err)
+	}
+	parts, err := parseThreadParts(string(content))
+	if err != nil {
+		log.Fatalf("cannot parse thread: %v", err)
+	}
+	if app.threadFile != "-" {
+		resolveMedia(parts, filepath.Dir(app.threadFile))
+	}
+
+	stateFile, err := app.threadStateFile(content)
+	if err != nil {
+		log.Fatalf("cannot locate thread state: %v", err)
+	}
+	state, err := loadThreadState(stateFile)
+	if err != nil {
+		log.Fatalf("cannot load thread state: %v", err)
+	}
+	if n := len(state.Posted); n > 0 {
+		fmt.Printf("resuming after %d of %d parts (last: %s)\n", n, len(parts), state.Posted[n-1])
+	}
+
+	if err := checkThreadMedia(parts, len(app.media)); err != nil {
+		log.Fatalf("cannot post thread: %v", err)
+	}
+	// Media given with -m go to the first part, so they are only uploaded
+	// when it is still to be posted.
+	if len(app.media) > 0 && len(state.Posted) == 0 {
+		app.uploadMedias()
+	}
+
+	first := tweetOptions{InReplyTo: app.inreply, MediaIDs: app.media, ReplySettings: app.replySettings}
+	posted, err := app.postParts(parts, first, state.Posted, func(posted []string) {
+		fmt.Println("tweeted:", posted[len(posted)-1])
+		state.Posted = posted
+		if err := saveThreadState(stateFile, state); err != nil {
+			log.Printf("cannot save thread state: %v", err)
+		}
+	})
+	if err != nil {
+		if len(posted) > 0 {
+			fmt.Println("thread:", strings.Join(posted, " "))
+		}
+		log.Fatalf("%v; run the same command again to resume", err)
+	}
+
+	os.Remove(stateFile)
+	fmt.Println("thread:", strings.Join(posted, " "))
+}
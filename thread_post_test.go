@@ -0,0 +1,123 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTweetLength(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"hello", 5},
+		{"こんにちは", 10},
+		{"see https://example.com/a/very/long/path/that/is/longer/than/23", 4 + urlLength},
+		{"a\nb", 3},
+		{"👩‍👩‍👧‍👦", 2},
+		{"👍🏽 🇯🇵", 5},
+		{"1️⃣", 2},
+	}
+	for _, tt := range tests {
+		if got := tweetLength(tt.in); got != tt.want {
+			t.Errorf("tweetLength(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSplitTweetTextShort(t *testing.T) {
+	got := splitTweetText("  hello world  ")
+	if !reflect.DeepEqual(got, []string{"hello world"}) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSplitTweetTextAtWords(t *testing.T) {
+	text := strings.Repeat("word ", 100)
+	chunks := splitTweetText(text)
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(chunks))
+	}
+	for _, c := range chunks {
+		if tweetLength(c) > maxTweetLength {
+			t.Errorf("chunk too long: %d", tweetLength(c))
+		}
+		if strings.HasPrefix(c, "ord") || strings.HasSuffix(c, "wor") {
+			t.Errorf("chunk split inside a word: %q", c)
+		}
+	}
+}
+
+func TestSplitTweetTextWideCharacters(t *testing.T) {
+	chunks := splitTweetText(strings.Repeat("あ", 200))
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(chunks))
+	}
+	if tweetLength(chunks[0]) != maxTweetLength {
+		t.Errorf("first chunk length = %d, want %d", tweetLength(chunks[0]), maxTweetLength)
+	}
+}
+
+func TestSplitTweetTextKeepsEmojiSequences(t *testing.T) {
+	family := "👩‍👩‍👧‍👦"
+	chunks := splitTweetText(strings.Repeat(family, 141))
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(chunks))
+	}
+	if chunks[0] != strings.Repeat(family, 140) || chunks[1] != family {
+		t.Errorf("emoji sequence split across chunks: %q", chunks)
+	}
+}
+
+func TestParseThreadParts(t *testing.T) {
+	content := "first tweet\n![chart](chart.png)\n---\nsecond\nline\n---\n![](a.png)\n"
+	got, err := parseThreadParts(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []threadPart{
+		{Text: "first tweet", Media: []string{"chart.png"}},
+		{Text: "second\nline"},
+		{Text: "", Media: []string{"a.png"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParseThreadPartsAutoSplit(t *testing.T) {
+	got, err := parseThreadParts(strings.Repeat("word ", 100) + "\n![](a.png)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d parts, want 2", len(got))
+	}
+	if len(got[0].Media) != 1 || len(got[1].Media) != 0 {
+		t.Errorf("media should stay with the first chunk: %#v", got)
+	}
+}
+
+func TestParseThreadPartsEmpty(t *testing.T) {
+	if _, err := parseThreadParts("\n---\n\n"); err == nil {
+		t.Errorf("expected error for empty thread")
+	}
+}
+
+func TestCheckThreadMedia(t *testing.T) {
+	parts := []threadPart{
+		{Text: "one", Media: []string{"a.png", "b.png"}},
+		{Text: "two", Media: []string{"c.png", "d.png", "e.png", "f.png"}},
+	}
+	if err := checkThreadMedia(parts, 2); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := checkThreadMedia(parts, 3); err == nil {
+		t.Error("expected error for 5 media in the first part")
+	}
+	parts[1].Media = append(parts[1].Media, "g.png")
+	if err := checkThreadMedia(parts, 0); err == nil {
+		t.Error("expected error for 5 media in the second part")
+	}
+}
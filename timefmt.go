@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultTimeFormat = "%Y-%m-%d %H:%M:%S %Z"
+
+// timeDisplay is how times of tweets are shown. It is set up from -tz and
+// -time-format, or from the configuration.
+type timeDisplay struct {
+	Location *time.Location
+	Format   string
+	// Absolute shows the formatted time in the default mode too, instead of
+	// the time relative to now.
+	Absolute bool
+}
+
+var displayTime = timeDisplay{Location: time.Local, Format: defaultTimeFormat}
+
+func newTimeDisplay(zone string, format string, absolute bool) (timeDisplay, error) {
+	d := timeDisplay{Location: time.Local, Format: defaultTimeFormat, Absolute: absolute}
+	if zone != "" {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return d, fmt.Errorf("unknown time zone %q: %v", zone, err)
+		}
+		d.Location = loc
+	}
+	if format != "" {
+		d.Format = format
+	}
+	return d, nil
+}
+
+func parseTweetTime(s string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, s)
+	return t, err == nil
+}
+
+// compact formats createdAt for the default mode, like "3m" or "Oct 12", or
+// with Format when Absolute is set.
+func (d timeDisplay) compact(createdAt string, now time.Time) string {
+	t, ok := parseTweetTime(createdAt)
+	if !ok {
+		return createdAt
+	}
+	if d.Absolute {
+		return strftime(t.In(d.Location), d.Format)
+	}
+	return relativeTime(t.In(d.Location), now.In(d.Location), "")
+}
+
+// full formats createdAt for the verbose mode.
+func (d timeDisplay) full(createdAt string) string {
+	t, ok := parseTweetTime(createdAt)
+	if !ok {
+		return createdAt
+	}
+	return strftime(t.In(d.Location), d.Format)
+}
+
+// strftime formats t with the conversions of C's strftime: %Y %y %m %d %e
+// %H %I %M %S %p %a %A %b %B %j %Z %z %s %F %T %R and %%. Other characters,
+// and unknown conversions, are kept as they are.
+func strftime(t time.Time, format string) string {
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			sb.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'Y':
+			sb.WriteString(strconv.Itoa(t.Year()))
+		case 'y':
+			fmt.Fprintf(&sb, "%02d", t.Year()%100)
+		case 'm':
+			fmt.Fprintf(&sb, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(&sb, "%02d", t.Day())
+		case 'e':
+			fmt.Fprintf(&sb, "%2d", t.Day())
+		case 'H':
+			fmt.Fprintf(&sb, "%02d", t.Hour())
+		case 'I':
+			fmt.Fprintf(&sb, "%02d", (t.Hour()+11)%12+1)
+		case 'M':
+			fmt.Fprintf(&sb, "%02d", t.Minute())
+		case 'S':
+			fmt.Fprintf(&sb, "%02d", t.Second())
+		case 'p':
+			sb.WriteString(t.Format("PM"))
+		case 'a':
+			sb.WriteString(t.Format("Mon"))
+		case 'A':
+			sb.WriteString(t.Format("Monday"))
+		case 'b':
+			sb.WriteString(t.Format("Jan"))
+		case 'B':
+			sb.WriteString(t.Format("January"))
+		case 'j':
+			fmt.Fprintf(&sb, "%03d", t.YearDay())
+		case 'Z':
+			sb.WriteString(t.Format("MST"))
+		case 'z':
+			sb.WriteString(t.Format("-0700"))
+		case 's':
+			sb.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'F':
+			sb.WriteString(t.Format("2006-01-02"))
+		case 'T':
+			sb.WriteString(t.Format("15:04:05"))
+		case 'R':
+			sb.WriteString(t.Format("15:04"))
+		case '%':
+			sb.WriteByte('%')
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(format[i])
+		}
+	}
+	return sb.String()
+}
+
+// relativeTime formats t as the time elapsed until now followed by suffix,
+// like "45s", "3m", "2h" or "5d" with an empty suffix, and as the date for
+// times more than 30 days ago.
+func relativeTime(t time.Time, now time.Time, suffix string) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Second:
+		return "now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds())) + suffix
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes())) + suffix
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours())) + suffix
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24)) + suffix
+	}
+	if t.Year() == now.Year() {
+		return t.Format("Jan 2")
+	}
+	return t.Format("Jan 2, 2006")
+}
+
+// mcpTweetTime formats the time of tweet for text given to assistants, with
+// the absolute time in UTC and the time relative to now.
+func mcpTweetTime(tweet V2Tweet, now time.Time) string {
+	t, ok := parseTweetTime(tweet.CreatedAt)
+	if !ok {
+		return ""
+	}
+	return " " + t.UTC().Format(time.RFC3339) + " (" + relativeTime(t, now, " ago") + ")"
+}
+
+// setupTimeDisplay sets up displayTime from -tz, -time-format and
+// -absolute-time, falling back to the configuration.
+func (app *App) setupTimeDisplay() {
+	zone, format := app.timeZone, app.timeFormat
+	if zone == "" {
+		zone = app.config.TimeZone
+	}
+	if format == "" {
+		format = app.config.TimeFormat
+	}
+	d, err := newTimeDisplay(zone, format, app.absoluteTime || app.config.AbsoluteTime)
+	if err != nil {
+		log.Fatal(err)
+	}
+	displayTime = d
+}
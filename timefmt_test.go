@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStrftime(t *testing.T) {
+	tm := time.Date(2026, 3, 5, 14, 7, 9, 0, time.UTC)
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%Y-%m-%d %H:%M:%S", "2026-03-05 14:07:09"},
+		{"%F %T %Z %z", "2026-03-05 14:07:09 UTC +0000"},
+		{"%a %A %b %B %e %y", "Thu Thursday Mar March  5 26"},
+		{"%I:%M %p %j", "02:07 PM 064"},
+		{"%s", "1772719629"},
+		{"100%% %q %", "100% %q %"},
+	}
+	for _, tt := range tests {
+		if got := strftime(tm, tt.format); got != tt.want {
+			t.Errorf("strftime(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestRelativeTimeCompact(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now, "now"},
+		{now.Add(time.Minute), "now"},
+		{now.Add(-45 * time.Second), "45s"},
+		{now.Add(-3 * time.Minute), "3m"},
+		{now.Add(-2 * time.Hour), "2h"},
+		{time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC), "6d"},
+		{time.Date(2026, 8, 12, 9, 0, 0, 0, time.UTC), "Aug 12"},
+		{time.Date(2025, 10, 12, 9, 0, 0, 0, time.UTC), "Oct 12, 2025"},
+	}
+	for _, tt := range tests {
+		if got := relativeTime(tt.t, now, ""); got != tt.want {
+			t.Errorf("relativeTime(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestTimeDisplay(t *testing.T) {
+	d, err := newTimeDisplay("Asia/Tokyo", "", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	if got := d.compact("2026-10-18T11:30:00.000Z", now); got != "30m" {
+		t.Errorf("compact = %q, want %q", got, "30m")
+	}
+	if got := d.full("2026-10-18T11:30:00.000Z"); got != "2026-10-18 20:30:00 JST" {
+		t.Errorf("full = %q, want %q", got, "2026-10-18 20:30:00 JST")
+	}
+	if got := d.full("garbage"); got != "garbage" {
+		t.Errorf("full of unparsable time = %q", got)
+	}
+
+	d, err = newTimeDisplay("UTC", "%H:%M", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := d.compact("2026-10-18T11:30:00.000Z", now); got != "30m" {
+		t.Errorf("compact with format = %q, want %q", got, "30m")
+	}
+	if got := d.full("2026-10-18T11:30:00.000Z"); got != "11:30" {
+		t.Errorf("full with format = %q, want %q", got, "11:30")
+	}
+
+	d, err = newTimeDisplay("UTC", "%H:%M", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := d.compact("2026-10-18T11:30:00.000Z", now); got != "11:30" {
+		t.Errorf("absolute compact = %q, want %q", got, "11:30")
+	}
+
+	if _, err := newTimeDisplay("Nowhere/City", "", false); err == nil {
+		t.Error("expected error for unknown time zone")
+	}
+}
+
+func TestFormatTweetsTextIncludesTime(t *testing.T) {
+	created := time.Now().Add(-2 * time.Hour).UTC()
+	res := V2TweetsResponse{
+		Data: []V2Tweet{{ID: "1", Text: "hi", AuthorID: "u1", CreatedAt: created.Format(time.RFC3339)}},
+		Includes: V2Includes{
+			Users: []V2User{{ID: "u1", Name: "Alice", Username: "alice"}},
+		},
+	}
+	want := "@alice (Alice) [1] " + created.Format(time.RFC3339) + " (2h ago):\nhi"
+	if got := formatTweetsText(res); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import "testing"
+
+func TestValidateTweetOptionsPlain(t *testing.T) {
+	if err := validateTweetOptions("hello", tweetOptions{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateTweetOptionsQuoteWithReply(t *testing.T) {
+	opts := tweetOptions{InReplyTo: "1", QuoteTweetID: "2"}
+	if err := validateTweetOptions("hello", opts); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateTweetOptionsQuoteWithMedia(t *testing.T) {
+	opts := tweetOptions{QuoteTweetID: "2", MediaIDs: []string{"3"}}
+	if err := validateTweetOptions("hello", opts); err == nil {
+		t.Errorf("expected error for quote with media")
+	}
+}
+
+func TestValidateTweetOptionsQuoteWithoutText(t *testing.T) {
+	if err := validateTweetOptions("", tweetOptions{QuoteTweetID: "2"}); err == nil {
+		t.Errorf("expected error for quote without text")
+	}
+}
+
+func TestParseTweetID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1234567890", "1234567890"},
+		{"https://x.com/mattn_jp/status/1234567890", "1234567890"},
+		{"https://twitter.com/mattn_jp/status/1234567890?s=20", "1234567890"},
+		{"https://x.com/i/web/status/1234567890", "1234567890"},
+	}
+	for _, tt := range tests {
+		got, err := parseTweetID(tt.in)
+		if err != nil {
+			t.Errorf("parseTweetID(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseTweetID(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTweetIDInvalid(t *testing.T) {
+	for _, in := range []string{"", "abc", "https://x.com/mattn_jp"} {
+		if _, err := parseTweetID(in); err == nil {
+			t.Errorf("parseTweetID(%q) expected error", in)
+		}
+	}
+}
+
+func TestValidateTweetOptionsReplySettings(t *testing.T) {
+	for _, rs := range []string{"", "following", "mentionedUsers", "subscribers"} {
+		if err := validateTweetOptions("hello", tweetOptions{ReplySettings: rs}); err != nil {
+			t.Errorf("%q: unexpected error: %v", rs, err)
+		}
+	}
+	if err := validateTweetOptions("hello", tweetOptions{ReplySettings: "everyone"}); err == nil {
+		t.Errorf("expected error for unknown reply settings")
+	}
+}
+
+func TestValidateTweetOptionsEdit(t *testing.T) {
+	if err := validateTweetOptions("fixed", tweetOptions{EditTweetID: "1"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateTweetOptions("", tweetOptions{EditTweetID: "1", MediaIDs: []string{"m"}}); err != nil {
+		t.Errorf("unexpected error for media-only edit: %v", err)
+	}
+	if err := validateTweetOptions("", tweetOptions{EditTweetID: "1"}); err == nil {
+		t.Errorf("expected error for empty edit")
+	}
+	if err := validateTweetOptions("fixed", tweetOptions{EditTweetID: "1", InReplyTo: "2"}); err == nil {
+		t.Errorf("expected error for edit with reply")
+	}
+}
@@ -4,7 +4,7 @@ import "testing"
 
 func TestTweetTextPlain(t *testing.T) {
 	tw := V2Tweet{Text: "hello"}
-	if got := tweetText(tw, nil); got != "hello" {
+	if got := tweetText(tw, nil, nil); got != "hello" {
 		t.Errorf("got %q, want %q", got, "hello")
 	}
 }
@@ -15,7 +15,7 @@ func TestTweetTextRetweet(t *testing.T) {
 		ReferencedTweets: []V2ReferencedTweet{{Type: "retweeted", ID: "1"}},
 	}
 	tm := map[string]V2Tweet{"1": {ID: "1", Text: "original"}}
-	if got := tweetText(tw, tm); got != "RT: original" {
+	if got := tweetText(tw, tm, nil); got != "RT: original" {
 		t.Errorf("got %q, want %q", got, "RT: original")
 	}
 }
@@ -25,7 +25,7 @@ func TestTweetTextRetweetMissingReference(t *testing.T) {
 		Text:             "fallback",
 		ReferencedTweets: []V2ReferencedTweet{{Type: "retweeted", ID: "x"}},
 	}
-	if got := tweetText(tw, nil); got != "fallback" {
+	if got := tweetText(tw, nil, nil); got != "fallback" {
 		t.Errorf("got %q, want %q", got, "fallback")
 	}
 }
@@ -37,7 +37,7 @@ func TestTweetTextQuoted(t *testing.T) {
 	}
 	tm := map[string]V2Tweet{"q": {ID: "q", Text: "quoted body"}}
 	want := "my comment\n  > quoted body"
-	if got := tweetText(tw, tm); got != want {
+	if got := tweetText(tw, tm, nil); got != want {
 		t.Errorf("got %q, want %q", got, want)
 	}
 }
@@ -54,7 +54,7 @@ func TestTweetTextRetweetTakesPrecedenceOverQuoted(t *testing.T) {
 		"q": {ID: "q", Text: "quote"},
 		"r": {ID: "r", Text: "retweet"},
 	}
-	if got := tweetText(tw, tm); got != "RT: retweet" {
+	if got := tweetText(tw, tm, nil); got != "RT: retweet" {
 		t.Errorf("got %q, want %q", got, "RT: retweet")
 	}
 }
@@ -64,7 +64,58 @@ func TestTweetTextRepliedToFallsThrough(t *testing.T) {
 		Text:             "reply body",
 		ReferencedTweets: []V2ReferencedTweet{{Type: "replied_to", ID: "p"}},
 	}
-	if got := tweetText(tw, nil); got != "reply body" {
+	if got := tweetText(tw, nil, nil); got != "reply body" {
 		t.Errorf("got %q, want %q", got, "reply body")
 	}
 }
+
+func TestTweetTextRetweetAttribution(t *testing.T) {
+	tw := V2Tweet{
+		Text:             "RT @bob: original tru…",
+		ReferencedTweets: []V2ReferencedTweet{{Type: "retweeted", ID: "1"}},
+	}
+	tm := map[string]V2Tweet{"1": {ID: "1", Text: "original", AuthorID: "u2"}}
+	um := map[string]V2User{"u2": {ID: "u2", Username: "bob"}}
+	if got := tweetText(tw, tm, um); got != "RT @bob: original" {
+		t.Errorf("got %q, want %q", got, "RT @bob: original")
+	}
+}
+
+func TestTweetTextQuotedAttribution(t *testing.T) {
+	tw := V2Tweet{
+		Text:             "my comment",
+		ReferencedTweets: []V2ReferencedTweet{{Type: "quoted", ID: "q"}},
+	}
+	tm := map[string]V2Tweet{"q": {ID: "q", Text: "quoted body", AuthorID: "u2"}}
+	um := map[string]V2User{"u2": {ID: "u2", Username: "bob"}}
+	want := "my comment\n  > @bob: quoted body"
+	if got := tweetText(tw, tm, um); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTweetTextPrefersNoteTweet(t *testing.T) {
+	long := V2Tweet{
+		Text: "the first 280 characters…",
+		NoteTweet: &V2NoteTweet{
+			Text: "the whole long post https://t.co/n",
+			Entities: &V2Entities{
+				URLs: []V2URLEntity{{URL: "https://t.co/n", ExpandedURL: "https://go.dev/"}},
+			},
+		},
+	}
+	if got, want := tweetText(long, nil, nil), "the whole long post https://go.dev/"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	tw := V2Tweet{
+		Text:             "RT @bob: the first…",
+		ReferencedTweets: []V2ReferencedTweet{{Type: "retweeted", ID: "1"}},
+	}
+	long.AuthorID = "u2"
+	tm := map[string]V2Tweet{"1": long}
+	um := map[string]V2User{"u2": {ID: "u2", Username: "bob"}}
+	if got, want := tweetText(tw, tm, um), "RT @bob: the whole long post https://go.dev/"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
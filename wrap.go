@@ -0,0 +1,181 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/clipperhouse/uax29/v2/graphemes"
+	"github.com/mattn/go-runewidth"
+)
+
+// minWrapWidth is the narrowest width text is wrapped to. Narrower terminals
+// get the text unwrapped, as wrapping would leave a few characters per line.
+const minWrapWidth = 20
+
+// stringWidth returns the number of terminal columns s takes, ignoring
+// escape sequences.
+func stringWidth(s string) int {
+	w := 0
+	for _, t := range splitWrapTokens(s) {
+		w += t.width
+	}
+	return w
+}
+
+type wrapTokenKind int
+
+const (
+	wrapWord wrapTokenKind = iota
+	wrapSpace
+	wrapNewline
+	wrapEscape
+)
+
+type wrapToken struct {
+	kind  wrapTokenKind
+	text  string
+	width int
+	// wide is set for a word of one wide character.
+	wide bool
+}
+
+// escapeEnd returns the end of the escape sequence that starts at s[i].
+// CSI sequences end with a final byte, OSC sequences with BEL or ST.
+func escapeEnd(s string, i int) int {
+	if i+1 >= len(s) {
+		return len(s)
+	}
+	switch s[i+1] {
+	case '[':
+		for j := i + 2; j < len(s); j++ {
+			if s[j] >= 0x40 && s[j] <= 0x7E {
+				return j + 1
+			}
+		}
+	case ']':
+		for j := i + 2; j < len(s); j++ {
+			if s[j] == '\a' {
+				return j + 1
+			}
+			if s[j] == 0x1b && j+1 < len(s) && s[j+1] == '\\' {
+				return j + 2
+			}
+		}
+	default:
+		return i + 2
+	}
+	return len(s)
+}
+
+// splitWrapTokens splits s into words, spaces, newlines and escape
+// sequences, measuring grapheme clusters so emoji sequences keep their
+// width. Each wide character is a word of its own, since lines of East
+// Asian text can break between any two characters.
+func splitWrapTokens(s string) []wrapToken {
+	var tokens []wrapToken
+	last := func() *wrapToken {
+		if len(tokens) == 0 {
+			return nil
+		}
+		return &tokens[len(tokens)-1]
+	}
+
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b {
+			end := escapeEnd(s, i)
+			tokens = append(tokens, wrapToken{kind: wrapEscape, text: s[i:end]})
+			i = end
+			continue
+		}
+		end := strings.IndexByte(s[i:], 0x1b)
+		if end < 0 {
+			end = len(s)
+		} else {
+			end += i
+		}
+		g := graphemes.FromString(s[i:end])
+		i = end
+
+		for g.Next() {
+			c := g.Value()
+			switch c {
+			case "\n", "\r\n":
+				tokens = append(tokens, wrapToken{kind: wrapNewline, text: "\n"})
+				continue
+			case " ", "\t":
+				if t := last(); t != nil && t.kind == wrapSpace {
+					t.text += " "
+					t.width++
+				} else {
+					tokens = append(tokens, wrapToken{kind: wrapSpace, text: " ", width: 1})
+				}
+				continue
+			}
+
+			w := runewidth.StringWidth(c)
+			t := last()
+			switch {
+			case w == 0 && t != nil && t.kind == wrapWord:
+				t.text += c
+			case w == 1 && t != nil && t.kind == wrapWord && !t.wide:
+				t.text += c
+				t.width++
+			default:
+				tokens = append(tokens, wrapToken{kind: wrapWord, text: c, width: w, wide: w >= 2})
+			}
+		}
+	}
+	return tokens
+}
+
+// wrapText wraps text to width columns. used is the number of columns
+// already taken on the first line, and the following lines start with
+// indent. Explicit newlines are kept and get the indent too. When width is
+// 0, text is only indented.
+func wrapText(text string, width int, used int, indent string) string {
+	indentWidth := stringWidth(indent)
+	if width > 0 && width-indentWidth < minWrapWidth {
+		width = 0
+	}
+
+	var sb strings.Builder
+	col, lineStart := used, used
+	pending := ""
+	newline := func() {
+		sb.WriteString("\n" + indent)
+		col, lineStart = indentWidth, indentWidth
+		pending = ""
+	}
+	for _, t := range splitWrapTokens(text) {
+		switch t.kind {
+		case wrapEscape:
+			sb.WriteString(t.text)
+		case wrapNewline:
+			newline()
+		case wrapSpace:
+			pending += t.text
+		case wrapWord:
+			if width > 0 && col > lineStart && col+len(pending)+t.width > width {
+				newline()
+			}
+			sb.WriteString(pending)
+			col += len(pending)
+			pending = ""
+			if width > 0 && col+t.width > width {
+				// A word longer than the line, like a URL, is cut.
+				g := graphemes.FromString(t.text)
+				for g.Next() {
+					w := runewidth.StringWidth(g.Value())
+					if w > 0 && col+w > width && col > lineStart {
+						newline()
+					}
+					sb.WriteString(g.Value())
+					col += w
+				}
+				continue
+			}
+			sb.WriteString(t.text)
+			col += t.width
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"a", 1},
+		{"é", 1},
+		{"あ", 2},
+		{"漢", 2},
+		{"한", 2},
+		{"Ａ", 2},
+		{"ｱ", 1},
+		{"😀", 2},
+		{"🍣", 2},
+		{"e\u0301", 1},
+		{"👍🏽", 2},
+		{"👨‍👩‍👧", 2},
+	}
+	for _, tt := range tests {
+		if got := stringWidth(tt.s); got != tt.want {
+			t.Errorf("stringWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestStringWidthSkipsEscapes(t *testing.T) {
+	s := "\x1b[91mこんにちは\x1b[0m " + hyperlink("https://go.dev/", "go.dev")
+	if got := stringWidth(s); got != 17 {
+		t.Errorf("stringWidth(%q) = %d, want 17", s, got)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		width  int
+		used   int
+		indent string
+		want   string
+	}{
+		{
+			name:  "words",
+			text:  "the quick brown fox jumps over the lazy dog",
+			width: 24, used: 6, indent: "  ",
+			want: "the quick brown\n  fox jumps over the\n  lazy dog",
+		},
+		{
+			name:  "japanese",
+			text:  "今日はとても良い天気ですね。散歩に行きましょう。",
+			width: 24, used: 4, indent: "  ",
+			want: "今日はとても良い天気\n  ですね。散歩に行きまし\n  ょう。",
+		},
+		{
+			name:  "newlines",
+			text:  "first line\nsecond line",
+			width: 0, used: 2, indent: "  ",
+			want: "first line\n  second line",
+		},
+		{
+			name:  "long word",
+			text:  "see https://example.com/a/very/long/path/name",
+			width: 24, used: 0, indent: "  ",
+			want: "see\n  https://example.com/a/\n  very/long/path/name",
+		},
+		{
+			name:  "too narrow",
+			text:  "the quick brown fox jumps over the lazy dog",
+			width: 10, used: 0, indent: "  ",
+			want: "the quick brown fox jumps over the lazy dog",
+		},
+	}
+	for _, tt := range tests {
+		got := wrapText(tt.text, tt.width, tt.used, tt.indent)
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		if tt.width-len(tt.indent) < minWrapWidth {
+			continue
+		}
+		for i, line := range strings.Split(got, "\n") {
+			w := stringWidth(line)
+			if i == 0 {
+				w += tt.used
+			}
+			if w > tt.width {
+				t.Errorf("%s: line %d %q is %d columns wide", tt.name, i, line, w)
+			}
+		}
+	}
+}
+
+func TestWrapTextKeepsEscapes(t *testing.T) {
+	text := "hello " + hashtagColor.Sprint("#golang") + " world"
+	got := wrapText("\x1b[96m#golang\x1b[0m and more words here", 22, 0, "  ")
+	if want := "\x1b[96m#golang\x1b[0m and more words\n  here"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := wrapText(text, 0, 0, ""); got != text {
+		t.Errorf("got %q, want %q", got, text)
+	}
+}